@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/middleware"
+)
+
+// source is a loaded data file or URL together with the store it feeds.
+type source struct {
+	dataset string       // Dataset name ("" for the default dataset)
+	path    string       // File path or URL
+	store   *data.Store  // Store holding the loaded elements
+	remote  *data.Remote // Remote used to refresh URLs; nil for files
+}
+
+// withDataset annotates logger with the dataset name, if any.
+func (s source) withDataset(logger *slog.Logger) *slog.Logger {
+	if s.dataset == "" {
+		return logger
+	}
+	return logger.With("dataset", s.dataset)
+}
+
+// loadSource loads the elements at path, which may be a file or an HTTP(S)
+// URL, into a new store. With persist set, edits to the store are written
+// back to path.
+func loadSource(
+	ctx context.Context,
+	dataset, path string,
+	format data.Format,
+	opts data.Options,
+	persist bool,
+	client *http.Client,
+	logger *slog.Logger,
+) (source, error) {
+	src := source{dataset: dataset, path: path}
+	logger = src.withDataset(logger)
+
+	if persist {
+		if data.IsURL(path) || !data.CanWrite(path, format) {
+			logger.Error("cannot persist edits", "path", path, "format", format)
+			return source{}, fmt.Errorf("cannot persist edits to %s: only local JSON and NDJSON files are supported", path)
+		}
+		opts.PersistPath = path
+		opts.PersistFormat = format
+	}
+
+	var elements data.Elements
+	var validators data.Validators
+	var err error
+	if data.IsURL(path) {
+		src.remote = data.NewRemote(path, format, client)
+		elements, validators, err = src.remote.Fetch(ctx)
+	} else {
+		elements, err = data.LoadElements(path, format)
+	}
+	if err != nil {
+		logger.Error("load elements", "path", path, "err", err)
+		return source{}, err
+	}
+	if len(elements) == 0 {
+		logger.Error("no elements available after load", "path", path)
+		return source{}, errors.New("no elements available")
+	}
+	logger.Debug("loaded elements", "path", path, "count", len(elements))
+
+	if src.store, err = data.NewStore(elements, opts); err != nil {
+		logger.Error("build store", "path", path, "error", err)
+		return source{}, err
+	}
+	if src.remote != nil {
+		src.remote.Accept(validators)
+	}
+	if skipped := src.store.Skipped(); skipped != nil {
+		logger.Warn("skipped invalid elements", "path", path, "indexes", skipped.Indexes(), "error", skipped)
+	}
+
+	return src, nil
+}
+
+// loadDatasets loads the named datasets and the files in dir, sorted by name.
+func loadDatasets(
+	ctx context.Context,
+	paths map[string]string,
+	dir string,
+	format data.Format,
+	opts data.Options,
+	persist bool,
+	client *http.Client,
+	logger *slog.Logger,
+) ([]data.Dataset, []source, error) {
+	all := make(map[string]string, len(paths))
+	maps.Copy(all, paths)
+
+	if dir != "" {
+		files, err := data.DatasetFiles(dir)
+		if err != nil {
+			logger.Error("list datasets", "dir", dir, "error", err)
+			return nil, nil, err
+		}
+		for name, path := range files {
+			if _, exists := all[name]; exists {
+				logger.Error("duplicate dataset", "dataset", name, "path", path)
+				return nil, nil, fmt.Errorf("duplicate dataset %q", name)
+			}
+			all[name] = path
+		}
+	}
+
+	datasets := make([]data.Dataset, 0, len(all))
+	sources := make([]source, 0, len(all))
+	for _, name := range slices.Sorted(maps.Keys(all)) {
+		src, err := loadSource(ctx, name, all[name], format, opts, persist, client, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		datasets = append(datasets, data.Dataset{Name: name, Path: src.path, Store: src.store})
+		sources = append(sources, src)
+	}
+
+	return datasets, sources, nil
+}
+
+// loadAPIKeys parses the API key entries from the flags and the keys file.
+func loadAPIKeys(entries []string, path string) ([]middleware.APIKey, error) {
+	keys := make([]middleware.APIKey, 0, len(entries))
+	for _, entry := range entries {
+		key, err := middleware.ParseAPIKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if path == "" {
+		return keys, nil
+	}
+
+	fileKeys, err := middleware.LoadAPIKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, fileKeys...), nil
+}
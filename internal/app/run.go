@@ -2,13 +2,22 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/gi8lino/randomapi/internal/certs"
 	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/flag"
+	"github.com/gi8lino/randomapi/internal/handlers"
 	"github.com/gi8lino/randomapi/internal/logging"
+	"github.com/gi8lino/randomapi/internal/metrics"
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/gi8lino/randomapi/internal/render"
 	"github.com/gi8lino/randomapi/internal/routes"
 
 	"github.com/containeroo/httpgrace/server"
@@ -17,6 +26,10 @@ import (
 
 // Run is the main function of the application.
 func Run(ctx context.Context, version string, argv []string, stdOut, stdErr io.Writer) error {
+	if len(argv) > 0 && argv[0] == ValidateCommand {
+		return Validate(ctx, version, argv[1:], stdOut, stdErr)
+	}
+
 	// Parse command-line flags
 	flags, err := flag.ParseArgs(version, argv, stdOut)
 	if err != nil {
@@ -43,34 +56,214 @@ func Run(ctx context.Context, version string, argv []string, stdOut, stdErr io.W
 		)
 	}
 
-	// Load data elements from JSON file
-	elements, err := data.LoadElements(flags.DataPath)
+	// Parse response templates before loading data so mistakes fail fast.
+	renderer, err := render.New(render.Templates{Text: flags.TextTemplate, HTML: flags.HTMLTemplate})
 	if err != nil {
-		setupLog.Error("load elements", "path", flags.DataPath, "err", err)
+		setupLog.Error("invalid template", "error", err)
 		return err
 	}
-	if len(elements) == 0 {
-		setupLog.Error("no elements available after load", "path", flags.DataPath)
-		return errors.New("no elements available")
 
+	var decks *handlers.Decks
+	if flags.Selection == flag.SelectionShuffleBag {
+		if decks, err = handlers.NewDecks(flags.ShuffleClients); err != nil {
+			setupLog.Error("create shuffle bags", "error", err)
+			return err
+		}
+	}
+
+	apiKeys, err := loadAPIKeys(flags.APIKeys, flags.APIKeysFile)
+	if err != nil {
+		setupLog.Error("load API keys", "error", err)
+		return err
+	}
+
+	var limiter *middleware.RateLimiter
+	if flags.RateLimit > 0 {
+		limiter, err = middleware.NewRateLimiter(middleware.RateLimitOptions{
+			Rate:           flags.RateLimit,
+			Burst:          flags.RateLimitBurst,
+			KeyByAPIKey:    flags.RateLimitKey == flag.RateLimitKeyAPIKey,
+			APIKeys:        apiKeys,
+			TrustedProxies: flags.TrustedProxies,
+			MaxClients:     flags.RateLimitClients,
+		}, logger.With("component", "ratelimit"))
+		if err != nil {
+			setupLog.Error("create rate limiter", "error", err)
+			return err
+		}
+	}
+
+	var certReloader *certs.Reloader
+	if flags.TLSCertFile != "" {
+		clientAuth := tls.RequireAndVerifyClientCert
+		if flags.TLSClientAuth == flag.TLSClientAuthVerifyIfGiven {
+			clientAuth = tls.VerifyClientCertIfGiven
+		}
+		certReloader, err = certs.NewReloader(certs.Options{
+			CertFile:     flags.TLSCertFile,
+			KeyFile:      flags.TLSKeyFile,
+			ClientCAFile: flags.TLSClientCAFile,
+			ClientAuth:   clientAuth,
+			Interval:     flags.WatchInterval,
+		}, logger.With("component", "certs"))
+		if err != nil {
+			setupLog.Error("load TLS certificates", "error", err)
+			return err
+		}
+	}
+
+	// Load the default dataset and all named datasets
+	client := &http.Client{Timeout: flags.FetchTimeout}
+	opts := data.Options{WeightPointer: flags.WeightPointer, FilterFields: flags.FilterFields, CompressMinSize: flags.CompressMinSize}
+	if flags.SchemaPath != "" {
+		if opts.Schema, err = data.LoadSchema(flags.SchemaPath); err != nil {
+			setupLog.Error("load schema", "path", flags.SchemaPath, "error", err)
+			return err
+		}
+		opts.SkipInvalid = flags.OnInvalid == flag.OnInvalidSkip
+	}
+
+	var store *data.Store
+	var sources []source
+	if flags.DataPath != "" {
+		defaultOpts := opts
+		defaultOpts.WeightsPath = flags.WeightsPath
+		src, err := loadSource(ctx, "", flags.DataPath, flags.DataFormat, defaultOpts, flags.AdminPersist, client, setupLog)
+		if err != nil {
+			return err
+		}
+		store = src.store
+		sources = append(sources, src)
+	}
+
+	datasets, datasetSources, err := loadDatasets(ctx, flags.Datasets, flags.DatasetDir, flags.DataFormat, opts, flags.AdminPersist, client, setupLog)
+	if err != nil {
+		return err
+	}
+	sources = append(sources, datasetSources...)
+	if store == nil && len(datasets) == 0 {
+		setupLog.Error("no datasets configured", "dir", flags.DatasetDir)
+		return errors.New("no datasets configured")
 	}
-	setupLog.Debug("loaded elements", "count", len(elements))
 
 	// HTTP server
 	serverLog := logger.With("component", "server")
+	metricsRegistry := metrics.New()
+	readiness := &handlers.Readiness{}
 	router := routes.NewRouter(
 		serverLog,
 		flags.RoutePrefix,
-		elements,
+		store,
+		datasets,
+		routes.Options{
+			Seed:            flags.Seed,
+			MaxCount:        flags.MaxCount,
+			Renderer:        renderer,
+			Decks:           decks,
+			Location:        flags.Location,
+			IndexMaxAge:     flags.IndexMaxAge,
+			CompressMinSize: flags.CompressMinSize,
+			RateLimiter:     limiter,
+			APIKeys:         apiKeys,
+			AuthExempt:      flags.AuthExempt,
+			AdminToken:      flags.AdminToken,
+			OpenAPISchema:   flags.OpenAPISchema,
+
+			CORS: middleware.CORSOptions{
+				AllowedOrigins:   flags.CORSOrigins,
+				AllowedMethods:   flags.CORSMethods,
+				AllowedHeaders:   flags.CORSHeaders,
+				ExposedHeaders:   flags.CORSExposed,
+				MaxAge:           flags.CORSMaxAge,
+				AllowCredentials: flags.CORSCredentials,
+			},
+
+			Metrics:         metricsRegistry,
+			MetricsEndpoint: flags.MetricsAddr == "",
+
+			Version:           version,
+			DataSource:        flags.DataPath,
+			Readiness:         readiness,
+			MaxReloadFailures: uint64(flags.MaxReloadFails),
+
+			AccessLog:           flags.AccessLog,
+			AccessLogSkipHealth: flags.AccessLogSkip,
+		},
 	)
 
+	// Background workers stop once the signal context is canceled.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	ctx, stop := server.SignalContext(ctx)
 	defer stop()
 
-	if err := server.Run(ctx, flags.ListenAddr, router, serverLog); err != nil {
+	// Keep serving for --shutdown-delay after a signal while /readyz reports
+	// not ready, so load balancers stop routing before connections are drained.
+	serverCtx, cancelServer := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelServer(nil)
+	wg.Go(func() {
+		select {
+		case <-ctx.Done():
+		case <-serverCtx.Done():
+			return
+		}
+		readiness.StartDrain()
+		if flags.ShutdownDelay > 0 {
+			serverLog.Info("draining before shutdown", "delay", flags.ShutdownDelay)
+			timer := time.NewTimer(flags.ShutdownDelay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-serverCtx.Done():
+			}
+		}
+		cancelServer(context.Cause(ctx))
+	})
+
+	// Reload changed files and refresh URLs until shutdown.
+	for _, src := range sources {
+		switch {
+		case src.remote != nil && flags.RefreshInterval > 0:
+			refreshLog := src.withDataset(logger.With("component", "refresher"))
+			wg.Go(func() { src.remote.Refresh(ctx, src.store, flags.RefreshInterval, refreshLog) })
+		case src.remote == nil && flags.Watch:
+			watcher := data.NewWatcher(src.path, flags.DataFormat, src.store, flags.WatchInterval, src.withDataset(logger.With("component", "watcher")))
+			wg.Go(func() { watcher.Run(ctx) })
+		}
+	}
+
+	// Reload rotated certificates until shutdown.
+	var tlsConfig *tls.Config
+	if certReloader != nil {
+		tlsConfig = certReloader.TLSConfig()
+		wg.Go(func() { certReloader.Run(ctx) })
+	}
+
+	// Serve /metrics on its own listener; a failure there stops the app.
+	metricsErr := make(chan error, 1)
+	if flags.MetricsAddr != "" {
+		metricsLog := logger.With("component", "metrics")
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metricsRegistry.Handler())
+		wg.Go(func() {
+			if err := serve(serverCtx, flags.MetricsAddr, mux, tlsConfig, metricsLog); err != nil {
+				metricsLog.Error("metrics server run", "listen_address", flags.MetricsAddr, "error", err)
+				metricsErr <- err
+				stop()
+			}
+		})
+	}
+
+	if err := serve(serverCtx, flags.ListenAddr, router, tlsConfig, serverLog); err != nil {
 		setupLog.Error("server run", "listen_address", flags.ListenAddr, "error", err)
 		return err
 	}
 
-	return nil
+	select {
+	case err := <-metricsErr:
+		return err
+	default:
+		return nil
+	}
 }
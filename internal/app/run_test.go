@@ -3,8 +3,11 @@ package app_test
 import (
 	"bytes"
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,6 +42,255 @@ func TestRun(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("Success with dataset dir", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+		defer cancel()
+
+		tmp := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "jokes.json"), []byte(`["a"]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "quotes.json"), []byte(`["b"]`), 0o600))
+
+		args := []string{
+			"--dataset-dir=" + tmp,
+			"--listen-address=127.0.0.1:0",
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(ctx, "v1", args, &out, &errOut)
+		require.NoError(t, err)
+	})
+
+	t.Run("Shutdown delay keeps serving after cancel", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1]`), 0o600))
+
+		args := []string{
+			"--data-path=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--shutdown-delay=300ms",
+		}
+
+		var out, errOut bytes.Buffer
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+		defer cancel()
+		err := app.Run(ctx, "v1", args, &out, &errOut)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+		assert.Contains(t, out.String(), "draining before shutdown")
+	})
+
+	t.Run("Invalid template fails startup", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1]`), 0o600))
+
+		args := []string{
+			"--data-path=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--template={{.setup",
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "parse text template:")
+	})
+
+	t.Run("Missing API keys file fails startup", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1]`), 0o600))
+
+		args := []string{
+			"--data-path=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--api-keys-file=" + filepath.Join(t.TempDir(), "keys"),
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "read API keys:")
+	})
+
+	t.Run("Invalid elements fail startup", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		dataPath := filepath.Join(tmp, "data.json")
+		schemaPath := filepath.Join(tmp, "schema.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1, "two", 3]`), 0o600))
+		require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "integer"}`), 0o600))
+
+		args := []string{
+			"--data-path=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--schema-path=" + schemaPath,
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "1 element does not match the schema: /1: ")
+	})
+
+	t.Run("Invalid elements of a named dataset fail startup", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		dataPath := filepath.Join(tmp, "jokes.json")
+		schemaPath := filepath.Join(tmp, "schema.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1, "two"]`), 0o600))
+		require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "integer"}`), 0o600))
+
+		args := []string{
+			"--dataset=jokes=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--schema-path=" + schemaPath,
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "1 element does not match the schema: /1: ")
+	})
+
+	t.Run("Invalid TLS key pair fails startup", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		dataPath := filepath.Join(tmp, "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "tls.crt"), []byte("not a certificate"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "tls.key"), []byte("not a key"), 0o600))
+
+		args := []string{
+			"--data-path=" + dataPath,
+			"--listen-address=127.0.0.1:0",
+			"--tls-cert-file=" + filepath.Join(tmp, "tls.crt"),
+			"--tls-key-file=" + filepath.Join(tmp, "tls.key"),
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "load TLS key pair:")
+	})
+
+	t.Run("Duplicate dataset between flag and dir fails", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+
+		tmp := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "jokes.json"), []byte(`["a"]`), 0o600))
+
+		args := []string{
+			"--dataset-dir=" + tmp,
+			"--dataset=jokes=" + filepath.Join(tmp, "jokes.json"),
+			"--listen-address=127.0.0.1:0",
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(ctx, "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `duplicate dataset "jokes"`)
+	})
+
+	t.Run("Success with data URL", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+		defer cancel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`["remote"]`))
+		}))
+		defer srv.Close()
+
+		args := []string{
+			"--data-path=" + srv.URL + "/data.json",
+			"--refresh-interval=50ms",
+			"--listen-address=127.0.0.1:0",
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(ctx, "v1", args, &out, &errOut)
+		require.NoError(t, err)
+	})
+
+	t.Run("Named data URL is downloaded again after invalid content", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+		defer cancel()
+
+		var mu sync.Mutex
+		var invalidConditional int
+		requests := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			requests++
+			if r.Header.Get("If-None-Match") == `"invalid"` {
+				invalidConditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			if requests == 1 {
+				w.Header().Set("ETag", `"valid"`)
+				_, _ = w.Write([]byte(`["remote"]`))
+				return
+			}
+			w.Header().Set("ETag", `"invalid"`)
+			_, _ = w.Write([]byte(`[]`))
+		}))
+		defer srv.Close()
+
+		args := []string{
+			"--dataset=jokes=" + srv.URL + "/jokes.json",
+			"--refresh-interval=20ms",
+			"--listen-address=127.0.0.1:0",
+		}
+
+		var out, errOut bytes.Buffer
+		require.NoError(t, app.Run(ctx, "v1", args, &out, &errOut))
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Greater(t, requests, 2)
+		assert.Zero(t, invalidConditional)
+	})
+
+	t.Run("Unreachable data URL surfaces fetch error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+
+		args := []string{
+			"--data-path=" + srv.URL + "/data.json",
+			"--listen-address=127.0.0.1:0",
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(ctx, "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fetch data: unexpected status")
+	})
+
 	t.Run("Help requested prints usage and returns nil", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/containeroo/httpgrace/server"
+)
+
+// Timeouts of servers started by serve; they match the httpgrace defaults.
+const (
+	readHeaderTimeout = 10 * time.Second
+	writeTimeout      = 15 * time.Second
+	idleTimeout       = 60 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+// serve runs handler on addr until ctx is canceled, using TLS when tlsConfig
+// is non-nil.
+func serve(ctx context.Context, addr string, handler http.Handler, tlsConfig *tls.Config, logger *slog.Logger) error {
+	if tlsConfig == nil {
+		return server.Run(ctx, addr, handler, logger)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", "listenAddr", addr, "tls", true)
+		// Certificates come from tlsConfig, so no files are passed.
+		serveErr <- srv.ListenAndServeTLS("", "")
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down server", "cause", context.Cause(ctx))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		_ = srv.Close()
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/flag"
+
+	"github.com/containeroo/tinyflags"
+)
+
+// ValidateCommand is the argument selecting Validate instead of the server.
+const ValidateCommand = "validate"
+
+// report is the validation result of one dataset.
+type report struct {
+	dataset  string   // Dataset name ("default" for --data-path)
+	path     string   // File path or URL
+	problems []string // Everything that makes the dataset invalid
+	stats    stats    // Statistics of the loaded elements
+}
+
+// stats summarizes loaded elements.
+type stats struct {
+	elements int
+	minSize  int
+	maxSize  int
+	total    int
+	types    map[string]int // Elements per JSON type
+	fields   map[string]int // Object elements per top-level field
+}
+
+// Validate checks the data files configured by the server flags without
+// starting the server. It writes one report per dataset to stdOut: parse
+// errors with line and column, empty sets, duplicate elements, schema
+// violations and invalid weights, or statistics for valid datasets. It fails
+// when any dataset is invalid.
+func Validate(ctx context.Context, version string, argv []string, stdOut, stdErr io.Writer) error {
+	flags, err := flag.ParseArgs(version, argv, stdOut)
+	if err != nil {
+		if tinyflags.IsHelpRequested(err) || tinyflags.IsVersionRequested(err) {
+			_, _ = fmt.Fprint(stdOut, err)
+			return nil
+		}
+		_, _ = fmt.Fprintln(stdErr, err)
+		return err
+	}
+
+	var schema *data.Schema
+	if flags.SchemaPath != "" {
+		if schema, err = data.LoadSchema(flags.SchemaPath); err != nil {
+			_, _ = fmt.Fprintln(stdErr, err)
+			return err
+		}
+	}
+
+	paths := make(map[string]string, len(flags.Datasets))
+	maps.Copy(paths, flags.Datasets)
+	if flags.DatasetDir != "" {
+		files, err := data.DatasetFiles(flags.DatasetDir)
+		if err != nil {
+			_, _ = fmt.Fprintln(stdErr, err)
+			return err
+		}
+		for name, path := range files {
+			if _, exists := paths[name]; exists {
+				err := fmt.Errorf("duplicate dataset %q", name)
+				_, _ = fmt.Fprintln(stdErr, err)
+				return err
+			}
+			paths[name] = path
+		}
+	}
+
+	client := &http.Client{Timeout: flags.FetchTimeout}
+	var reports []report
+	if flags.DataPath != "" {
+		opts := data.Options{WeightPointer: flags.WeightPointer, WeightsPath: flags.WeightsPath, FilterFields: flags.FilterFields}
+		reports = append(reports, validateSource(ctx, "default", flags.DataPath, flags.DataFormat, opts, schema, client))
+	}
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		opts := data.Options{WeightPointer: flags.WeightPointer, FilterFields: flags.FilterFields}
+		reports = append(reports, validateSource(ctx, name, paths[name], flags.DataFormat, opts, schema, client))
+	}
+	if len(reports) == 0 {
+		err := errors.New("no datasets configured")
+		_, _ = fmt.Fprintln(stdErr, err)
+		return err
+	}
+
+	failed := 0
+	for _, r := range reports {
+		if len(r.problems) > 0 {
+			failed++
+		}
+		r.write(stdOut)
+	}
+	_, _ = fmt.Fprintf(stdOut, "\n%d datasets checked, %d failed\n", len(reports), failed)
+
+	if failed > 0 {
+		err := fmt.Errorf("validation failed: %d of %d datasets are invalid", failed, len(reports))
+		_, _ = fmt.Fprintln(stdErr, err)
+		return err
+	}
+	return nil
+}
+
+// validateSource loads and checks the elements at path.
+func validateSource(
+	ctx context.Context,
+	dataset, path string,
+	format data.Format,
+	opts data.Options,
+	schema *data.Schema,
+	client *http.Client,
+) report {
+	r := report{dataset: dataset, path: path}
+
+	var elements data.Elements
+	var err error
+	if data.IsURL(path) {
+		elements, _, err = data.NewRemote(path, format, client).Fetch(ctx)
+	} else {
+		elements, err = data.LoadElements(path, format)
+	}
+	if err != nil {
+		r.problems = append(r.problems, err.Error())
+		return r
+	}
+	if len(elements) == 0 {
+		r.problems = append(r.problems, "no elements")
+		return r
+	}
+	r.stats = elementStats(elements)
+
+	r.problems = append(r.problems, duplicates(elements)...)
+
+	if schema != nil {
+		var schemaErr *data.SchemaError
+		if errors.As(schema.Validate(elements), &schemaErr) {
+			for _, v := range schemaErr.Violations {
+				r.problems = append(r.problems, "schema: "+v.String())
+			}
+		}
+	}
+
+	// Building a store checks weights the way the server would.
+	if _, err := data.NewStore(elements, opts); err != nil {
+		r.problems = append(r.problems, err.Error())
+	}
+
+	return r
+}
+
+// duplicates reports every element equal to an earlier one, ignoring
+// insignificant whitespace.
+func duplicates(elements data.Elements) []string {
+	var problems []string
+	seen := make(map[string]int, len(elements))
+	for i, elem := range elements {
+		var buf bytes.Buffer
+		key := string(elem)
+		if err := json.Compact(&buf, elem); err == nil {
+			key = buf.String()
+		}
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("element %d duplicates element %d", i, first))
+			continue
+		}
+		seen[key] = i
+	}
+	return problems
+}
+
+// elementStats summarizes the sizes, types and fields of elements.
+func elementStats(elements data.Elements) stats {
+	s := stats{
+		elements: len(elements),
+		minSize:  len(elements[0]),
+		types:    make(map[string]int),
+		fields:   make(map[string]int),
+	}
+	for _, elem := range elements {
+		s.total += len(elem)
+		s.minSize = min(s.minSize, len(elem))
+		s.maxSize = max(s.maxSize, len(elem))
+
+		var fields map[string]json.RawMessage
+		switch trimmed := bytes.TrimSpace(elem); {
+		case len(trimmed) == 0:
+			s.types["invalid"]++
+		case trimmed[0] == '{':
+			s.types["object"]++
+			if json.Unmarshal(trimmed, &fields) == nil {
+				for name := range fields {
+					s.fields[name]++
+				}
+			}
+		case trimmed[0] == '[':
+			s.types["array"]++
+		case trimmed[0] == '"':
+			s.types["string"]++
+		case trimmed[0] == 't' || trimmed[0] == 'f':
+			s.types["boolean"]++
+		case trimmed[0] == 'n':
+			s.types["null"]++
+		default:
+			s.types["number"]++
+		}
+	}
+	return s
+}
+
+// write prints the report.
+func (r report) write(w io.Writer) {
+	status := "ok"
+	if len(r.problems) > 0 {
+		status = "FAILED"
+	}
+	path := r.path
+	if u, err := url.Parse(path); err == nil && data.IsURL(path) {
+		path = u.Redacted()
+	}
+	_, _ = fmt.Fprintf(w, "%-7s %s (%s)\n", status, r.dataset, path)
+
+	for _, problem := range r.problems {
+		_, _ = fmt.Fprintf(w, "        %s\n", problem)
+	}
+	if r.stats.elements == 0 {
+		return
+	}
+
+	s := r.stats
+	_, _ = fmt.Fprintf(w, "        elements: %d, bytes: %d (min %d, avg %d, max %d)\n",
+		s.elements, s.total, s.minSize, s.total/s.elements, s.maxSize)
+	_, _ = fmt.Fprintf(w, "        types: %s\n", counts(s.types))
+	if len(s.fields) > 0 {
+		_, _ = fmt.Fprintf(w, "        fields: %s\n", counts(s.fields))
+	}
+}
+
+// counts formats counts as "name count" pairs sorted by name.
+func counts(m map[string]int) string {
+	parts := make([]string, 0, len(m))
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		parts = append(parts, fmt.Sprintf("%s %d", name, m[name]))
+	}
+	return strings.Join(parts, ", ")
+}
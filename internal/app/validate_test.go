@@ -0,0 +1,96 @@
+package app_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid data prints statistics", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[{"text":"a"},{"text":"b","author":"x"},"c"]`), 0o600))
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", []string{"validate", "--data-path=" + dataPath}, &out, &errOut)
+		require.NoError(t, err)
+
+		assert.Contains(t, out.String(), "ok      default ("+dataPath+")\n")
+		assert.Contains(t, out.String(), "elements: 3, bytes: ")
+		assert.Contains(t, out.String(), "types: object 2, string 1\n")
+		assert.Contains(t, out.String(), "fields: author 1, text 2\n")
+		assert.Contains(t, out.String(), "1 datasets checked, 0 failed\n")
+		assert.Empty(t, errOut.String())
+	})
+
+	t.Run("reports every problem and fails", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		dataPath := filepath.Join(tmp, "data.json")
+		schemaPath := filepath.Join(tmp, "schema.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1, 2, 1, "x"]`), 0o600))
+		require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "integer"}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "broken.json"), []byte("[\n  {\"a\" 1}\n]"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "empty.json"), []byte(`[]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "words.json"), []byte(`["a"]`), 0o600))
+
+		args := []string{
+			"validate",
+			"--data-path=" + dataPath,
+			"--schema-path=" + schemaPath,
+			"--dataset=broken=" + filepath.Join(tmp, "broken.json"),
+			"--dataset=empty=" + filepath.Join(tmp, "empty.json"),
+			"--dataset=words=" + filepath.Join(tmp, "words.json"),
+		}
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", args, &out, &errOut)
+		require.Error(t, err)
+		assert.EqualError(t, err, "validation failed: 4 of 4 datasets are invalid")
+
+		report := out.String()
+		assert.Contains(t, report, "element 2 duplicates element 0\n")
+		assert.Contains(t, report, "schema: /3: ")
+		assert.Contains(t, report, "FAILED  broken (")
+		assert.Contains(t, report, "line 2, column 8: invalid character '1' after object key\n")
+		assert.Contains(t, report, "FAILED  empty (")
+		assert.Contains(t, report, "no elements\n")
+		assert.Contains(t, report, "FAILED  words (")
+		assert.Contains(t, report, "schema: /0: ")
+		assert.Contains(t, errOut.String(), "validation failed:")
+	})
+
+	t.Run("invalid weights fail", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[{"w":1},{"w":-1}]`), 0o600))
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", []string{"validate", "--data-path=" + dataPath, "--weight-pointer=/w"}, &out, &errOut)
+		require.Error(t, err)
+		assert.Contains(t, out.String(), "FAILED  default (")
+	})
+
+	t.Run("missing schema fails", func(t *testing.T) {
+		t.Parallel()
+
+		dataPath := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(dataPath, []byte(`[1]`), 0o600))
+
+		var out, errOut bytes.Buffer
+		err := app.Run(t.Context(), "v1", []string{"validate", "--data-path=" + dataPath, "--schema-path=" + filepath.Join(t.TempDir(), "missing.json")}, &out, &errOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "load schema:")
+	})
+}
@@ -0,0 +1,139 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/watch"
+)
+
+// Options configures the TLS files served by a Reloader.
+type Options struct {
+	CertFile     string             // PEM certificate chain
+	KeyFile      string             // PEM private key
+	ClientCAFile string             // PEM CA bundle verifying client certificates ("" = no client certificates)
+	ClientAuth   tls.ClientAuthType // Client certificate policy when ClientCAFile is set
+	Interval     time.Duration      // Polling interval used when filesystem notifications are unavailable
+}
+
+// Reloader serves a TLS configuration built from certificate files and
+// rebuilds it whenever the files change, e.g. when cert-manager rotates them.
+type Reloader struct {
+	opts     Options
+	logger   *slog.Logger
+	current  atomic.Pointer[tls.Config]
+	contents [][]byte // file contents the current configuration was built from
+}
+
+// NewReloader loads the files in opts and returns a Reloader serving them.
+func NewReloader(opts Options, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{opts: opts, logger: logger}
+
+	contents, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := r.build(contents)
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(cfg)
+	r.contents = contents
+
+	return r, nil
+}
+
+// TLSConfig returns a server configuration that always hands out the most
+// recently loaded certificate and client CAs.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// Run watches the certificate files until ctx is canceled.
+func (r *Reloader) Run(ctx context.Context) {
+	watch.Files(ctx, r.paths(), r.opts.Interval, r.logger, r.reload)
+}
+
+// reload rebuilds the configuration if any file changed. The previous
+// configuration is kept if the new files are invalid, e.g. while a rotation
+// has replaced the certificate but not yet the key.
+func (r *Reloader) reload() {
+	contents, err := r.read()
+	if err != nil {
+		r.logger.Warn("read certificates, keeping previous ones", "error", err)
+		return
+	}
+	if slices.EqualFunc(contents, r.contents, bytes.Equal) {
+		return
+	}
+
+	cfg, err := r.build(contents)
+	if err != nil {
+		r.logger.Error("reload certificates, keeping previous ones", "error", err)
+		return
+	}
+	r.current.Store(cfg)
+	r.contents = contents
+	r.logger.Info("reloaded certificates", "cert_file", r.opts.CertFile)
+}
+
+// paths returns the files the configuration is built from.
+func (r *Reloader) paths() []string {
+	paths := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		paths = append(paths, r.opts.ClientCAFile)
+	}
+	return paths
+}
+
+// read returns the contents of all files.
+func (r *Reloader) read() ([][]byte, error) {
+	paths := r.paths()
+	contents := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS file: %w", err)
+		}
+		contents = append(contents, b)
+	}
+	return contents, nil
+}
+
+// build creates the TLS configuration from the contents of all files.
+func (r *Reloader) build(contents [][]byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(contents[0], contents[1])
+	if err != nil {
+		return nil, fmt.Errorf("load TLS key pair: %w", err)
+	}
+
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.opts.ClientCAFile != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(contents[2]) {
+			return nil, errors.New("load client CA bundle: no certificates found")
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = r.opts.ClientAuth
+	}
+	return cfg, nil
+}
@@ -0,0 +1,244 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	ca := newCA(t)
+
+	// writePair writes a server certificate for name signed by ca into dir.
+	writePair := func(t *testing.T, dir, name string) (certFile, keyFile string) {
+		t.Helper()
+
+		certPEM, keyPEM := ca.issue(t, name, x509.ExtKeyUsageServerAuth)
+		certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+		require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+		require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+		return certFile, keyFile
+	}
+
+	t.Run("serves the loaded certificate and reloads it on change", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certFile, keyFile := writePair(t, dir, "first")
+
+		r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile, Interval: time.Hour}, logger)
+		require.NoError(t, err)
+		addr := serveTLS(t, r.TLSConfig())
+
+		assert.Equal(t, "first", handshake(t, addr, ca.pool, nil).Subject.CommonName)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		var wg sync.WaitGroup
+		wg.Go(func() { r.Run(ctx) })
+		t.Cleanup(func() {
+			cancel()
+			wg.Wait()
+		})
+
+		writePair(t, dir, "second")
+		assert.Eventually(t, func() bool {
+			return handshake(t, addr, ca.pool, nil).Subject.CommonName == "second"
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keeps the previous certificate on invalid files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certFile, keyFile := writePair(t, dir, "first")
+
+		r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile}, logger)
+		require.NoError(t, err)
+		before := r.current.Load()
+
+		// A rotation that replaced the certificate but not yet the key.
+		certPEM, _ := ca.issue(t, "second", x509.ExtKeyUsageServerAuth)
+		require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+		r.reload()
+		assert.Same(t, before, r.current.Load())
+
+		require.NoError(t, os.Remove(keyFile))
+		r.reload()
+		assert.Same(t, before, r.current.Load())
+	})
+
+	t.Run("verifies client certificates", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certFile, keyFile := writePair(t, dir, "server")
+		caFile := filepath.Join(dir, "ca.crt")
+		require.NoError(t, os.WriteFile(caFile, ca.certPEM, 0o600))
+
+		r, err := NewReloader(Options{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}, logger)
+		require.NoError(t, err)
+		addr := serveTLS(t, r.TLSConfig())
+
+		certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+		client, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		assert.Equal(t, "server", handshake(t, addr, ca.pool, &client).Subject.CommonName)
+
+		// Without a client certificate the server rejects the connection.
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.pool, ServerName: "localhost"})
+		if err == nil {
+			defer conn.Close() // nolint:errcheck
+			_, err = conn.Read(make([]byte, 1))
+		}
+		require.Error(t, err)
+	})
+
+	t.Run("rejects invalid files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certFile, keyFile := writePair(t, dir, "server")
+		caFile := filepath.Join(dir, "ca.crt")
+		require.NoError(t, os.WriteFile(caFile, []byte("not a bundle"), 0o600))
+
+		_, err := NewReloader(Options{CertFile: certFile, KeyFile: filepath.Join(dir, "missing")}, logger)
+		require.ErrorContains(t, err, "read TLS file:")
+
+		_, err = NewReloader(Options{CertFile: certFile, KeyFile: certFile}, logger)
+		require.ErrorContains(t, err, "load TLS key pair:")
+
+		_, err = NewReloader(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}, logger)
+		require.ErrorContains(t, err, "load client CA bundle:")
+	})
+}
+
+// testCA is a self-signed certificate authority for tests.
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	pool    *x509.CertPool
+}
+
+// newCA returns a new test CA.
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pool:    pool,
+	}
+}
+
+// issue returns a PEM certificate and key for localhost with the common name
+// name, signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// serveTLS accepts TLS connections with cfg until the test ends and returns
+// the listener address.
+func serveTLS(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close() // nolint:errcheck
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					_, _ = conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// handshake connects to addr and returns the server's leaf certificate.
+func handshake(t *testing.T, addr string, roots *x509.CertPool, client *tls.Certificate) *x509.Certificate {
+	t.Helper()
+
+	cfg := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if client != nil {
+		cfg.Certificates = []tls.Certificate{*client}
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, cfg)
+	require.NoError(t, err)
+	defer conn.Close() // nolint:errcheck
+
+	return conn.ConnectionState().PeerCertificates[0]
+}
@@ -0,0 +1,172 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoding is an HTTP content coding.
+type Encoding string
+
+const (
+	Brotli Encoding = "br"
+	Zstd   Encoding = "zstd"
+	Gzip   Encoding = "gzip"
+)
+
+// Encodings lists the supported encodings in order of preference.
+var Encodings = []Encoding{Brotli, Zstd, Gzip}
+
+// Variants holds pre-compressed copies of a body by encoding. Encodings that
+// would not shrink the body are left out.
+type Variants map[Encoding][]byte
+
+// Precompress returns the variants of body compressed with every supported
+// encoding at its best ratio. It is meant for bodies compressed once and
+// served many times.
+func Precompress(body []byte) (Variants, error) {
+	variants := make(Variants, len(Encodings))
+	for _, enc := range Encodings {
+		var buf bytes.Buffer
+		w, err := newBestWriter(enc, &buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(body); err != nil {
+			return nil, fmt.Errorf("compress %s: %w", enc, err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("compress %s: %w", enc, err)
+		}
+		if buf.Len() < len(body) {
+			variants[enc] = buf.Bytes()
+		}
+	}
+	return variants, nil
+}
+
+// Negotiate returns the encoding among offered that the Accept-Encoding header
+// of r prefers, or "" when the body should be sent uncompressed. Ties are
+// broken by the order of offered.
+func Negotiate(r *http.Request, offered []Encoding) Encoding {
+	header := r.Header.Values("Accept-Encoding")
+	if len(header) == 0 {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, value := range header {
+		for part := range strings.SplitSeq(value, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			q := 1.0
+			if raw, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+					q = parsed
+				}
+			}
+			qualities[name] = q
+		}
+	}
+
+	var best Encoding
+	bestQ := 0.0
+	for _, enc := range offered {
+		q, ok := qualities[string(enc)]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// Vary adds Accept-Encoding to the Vary header of h unless already listed.
+func Vary(h http.Header) {
+	for _, value := range h.Values("Vary") {
+		for name := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// Weaken marks a strong ETag in h as weak, since a compressed body is not
+// byte-identical to the representation the strong tag was computed for.
+func Weaken(h http.Header) {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+}
+
+// writerPools caches writers for on-the-fly compression by encoding.
+var writerPools = map[Encoding]*sync.Pool{
+	Brotli: {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	Zstd: {New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)) // options are valid
+		return w
+	}},
+	Gzip: {New: func() any { return gzip.NewWriter(nil) }},
+}
+
+// pooledWriter is a compressing writer that can be reset onto a new target.
+type pooledWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+}
+
+// Writer compresses into an underlying writer using a pooled encoder.
+type Writer struct {
+	pooledWriter
+	pool *sync.Pool
+}
+
+// NewWriter returns a Writer compressing with enc into w. Close flushes the
+// compressed stream and returns the encoder to its pool; it does not close w.
+func NewWriter(enc Encoding, w io.Writer) (*Writer, error) {
+	pool, ok := writerPools[enc]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", enc)
+	}
+	pw := pool.Get().(pooledWriter)
+	pw.Reset(w)
+	return &Writer{pooledWriter: pw, pool: pool}, nil
+}
+
+// Close flushes the compressed stream and releases the encoder.
+func (w *Writer) Close() error {
+	err := w.pooledWriter.Close()
+	w.pool.Put(w.pooledWriter)
+	return err
+}
+
+// newBestWriter returns an encoder for enc writing into w at the highest
+// compression level.
+func newBestWriter(enc Encoding, w io.Writer) (io.WriteCloser, error) {
+	switch enc {
+	case Brotli:
+		return brotli.NewWriterLevel(w, brotli.BestCompression), nil
+	case Zstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+	case Gzip:
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", enc)
+	}
+}
@@ -0,0 +1,162 @@
+package compress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gi8lino/randomapi/internal/compress"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   compress.Encoding
+	}{
+		{header: "", want: ""},
+		{header: "identity", want: ""},
+		{header: "gzip", want: compress.Gzip},
+		{header: "gzip, deflate, br, zstd", want: compress.Brotli},
+		{header: "gzip;q=1.0, br;q=0.5", want: compress.Gzip},
+		{header: "zstd, br;q=0", want: compress.Zstd},
+		{header: "*", want: compress.Brotli},
+		{header: "*;q=0.1, gzip;q=0", want: compress.Brotli},
+		{header: "BR", want: compress.Brotli},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Accept-Encoding", tc.header)
+			}
+			assert.Equal(t, tc.want, compress.Negotiate(req, compress.Encodings))
+		})
+	}
+
+	t.Run("only offered encodings", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "br, zstd")
+		assert.Equal(t, compress.Zstd, compress.Negotiate(req, []compress.Encoding{compress.Gzip, compress.Zstd}))
+		assert.Equal(t, compress.Encoding(""), compress.Negotiate(req, []compress.Encoding{compress.Gzip}))
+	})
+}
+
+func TestPrecompress(t *testing.T) {
+	t.Parallel()
+
+	t.Run("variants decode to the body", func(t *testing.T) {
+		t.Parallel()
+
+		body := []byte(`{"text":"` + strings.Repeat("all work and no play ", 100) + `"}`)
+		variants, err := compress.Precompress(body)
+		require.NoError(t, err)
+		require.Len(t, variants, 3)
+
+		for enc, compressed := range variants {
+			assert.Less(t, len(compressed), len(body))
+			assert.Equal(t, body, decode(t, enc, compressed), enc)
+		}
+	})
+
+	t.Run("skips variants that do not shrink", func(t *testing.T) {
+		t.Parallel()
+
+		variants, err := compress.Precompress([]byte(`1`))
+		require.NoError(t, err)
+		assert.Empty(t, variants)
+	})
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, enc := range compress.Encodings {
+		t.Run(string(enc), func(t *testing.T) {
+			t.Parallel()
+
+			// Run twice to exercise writers returned to the pool.
+			for range 2 {
+				var buf bytes.Buffer
+				w, err := compress.NewWriter(enc, &buf)
+				require.NoError(t, err)
+				_, err = w.Write([]byte("hello hello hello"))
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+
+				assert.Equal(t, "hello hello hello", string(decode(t, enc, buf.Bytes())))
+			}
+		})
+	}
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := compress.NewWriter("deflate", io.Discard)
+		require.Error(t, err)
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("vary is added once", func(t *testing.T) {
+		t.Parallel()
+
+		h := http.Header{}
+		h.Add("Vary", "Accept")
+		compress.Vary(h)
+		compress.Vary(h)
+		assert.Equal(t, []string{"Accept", "Accept-Encoding"}, h.Values("Vary"))
+	})
+
+	t.Run("weaken marks strong etags weak", func(t *testing.T) {
+		t.Parallel()
+
+		h := http.Header{}
+		compress.Weaken(h)
+		assert.Empty(t, h.Get("ETag"))
+
+		h.Set("ETag", `"abc"`)
+		compress.Weaken(h)
+		compress.Weaken(h)
+		assert.Equal(t, `W/"abc"`, h.Get("ETag"))
+	})
+}
+
+// decode decompresses b encoded with enc.
+func decode(t *testing.T, enc compress.Encoding, b []byte) []byte {
+	t.Helper()
+
+	var r io.Reader
+	switch enc {
+	case compress.Gzip:
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		r = gr
+	case compress.Brotli:
+		r = brotli.NewReader(bytes.NewReader(b))
+	case compress.Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
@@ -13,16 +13,27 @@ type Element = json.RawMessage
 // Elements is the in-memory representation of the JSON array.
 type Elements []Element
 
-// LoadElements loads a JSON file that contains an array of elements.
-func LoadElements(path string) (Elements, error) {
-	var elements Elements
-
-	data, err := os.ReadFile(path)
+// LoadElements loads a data file that contains a list of elements. With
+// FormatAuto the format is detected from the file extension.
+func LoadElements(path string, format Format) (Elements, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return Elements{}, fmt.Errorf("read data: %w", err)
 	}
+	defer f.Close() // nolint:errcheck
+
+	return decodeElements(f, format.resolve(path))
+}
+
+// parseElements decodes a JSON array into elements.
+func parseElements(data []byte) (Elements, error) {
+	var elements Elements
 
 	if err := json.Unmarshal(data, &elements); err != nil {
+		if offset, ok := errorOffset(err); ok {
+			line, column := position(data, offset)
+			return Elements{}, fmt.Errorf("unmarshal data: line %d, column %d: %w", line, column, err)
+		}
 		return Elements{}, fmt.Errorf("unmarshal data: %w", err)
 	}
 
@@ -28,7 +28,7 @@ func TestLoadElements(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
 
-		elements, err := data.LoadElements(path)
+		elements, err := data.LoadElements(path, data.FormatAuto)
 		require.NoError(t, err)
 
 		assert.Len(t, elements, 4)
@@ -43,7 +43,7 @@ func TestLoadElements(t *testing.T) {
 	t.Run("returns error on missing file", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := data.LoadElements("/does/not/exist.json")
+		_, err := data.LoadElements("/does/not/exist.json", data.FormatAuto)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "read data")
 	})
@@ -57,7 +57,7 @@ func TestLoadElements(t *testing.T) {
 		// not an array, not even valid JSON
 		require.NoError(t, os.WriteFile(path, []byte(`{ this is not valid json ]`), 0o600))
 
-		_, err := data.LoadElements(path)
+		_, err := data.LoadElements(path, data.FormatAuto)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unmarshal data")
 	})
@@ -70,8 +70,21 @@ func TestLoadElements(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(path, []byte(`{"a":1,"b":2}`), 0o600))
 
-		_, err := data.LoadElements(path)
+		_, err := data.LoadElements(path, data.FormatAuto)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unmarshal data")
 	})
+
+	t.Run("reports line and column of syntax errors", func(t *testing.T) {
+		t.Parallel()
+
+		tmp := t.TempDir()
+		path := filepath.Join(tmp, "broken.json")
+
+		require.NoError(t, os.WriteFile(path, []byte("[\n  {\"text\": \"a\"},\n  {\"text\" \"b\"}\n]\n"), 0o600))
+
+		_, err := data.LoadElements(path, data.FormatAuto)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unmarshal data: line 3, column 11: invalid character")
+	})
 }
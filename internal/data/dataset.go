@@ -0,0 +1,78 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// datasetNamePattern restricts dataset names to a single URL path segment.
+var datasetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reservedDatasetNames are taken by the default dataset and by the routes
+// mounted at the root, whose paths, metrics and client decks a dataset of the
+// same name would share.
+var reservedDatasetNames = []string{
+	"default", "admin", "datasets", "healthz", "readyz", "metrics",
+	"random", "index", "daily", "hourly", "period",
+}
+
+// Dataset is a named element set served under /{Name}.
+type Dataset struct {
+	Name  string // URL path segment
+	Path  string // Source file
+	Store *Store // Elements currently served
+}
+
+// ValidateDatasetName reports whether name can be used as a dataset name.
+func ValidateDatasetName(name string) error {
+	if !datasetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid dataset name %q: only letters, digits, '-' and '_' are allowed", name)
+	}
+	if slices.Contains(reservedDatasetNames, name) {
+		return fmt.Errorf("invalid dataset name %q: reserved", name)
+	}
+	return nil
+}
+
+// DatasetFiles returns the data files in dir keyed by dataset name, which is
+// the file name without its extension. Hidden files and files without a
+// supported extension are ignored.
+func DatasetFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dataset dir: %w", err)
+	}
+
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, ok := formatExtensions[strings.ToLower(filepath.Ext(name))]; !ok || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		// Follow symlinks (e.g. Kubernetes ConfigMap mounts) before checking the type.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat dataset file: %w", err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		datasetName := strings.TrimSuffix(name, filepath.Ext(name))
+		if err := ValidateDatasetName(datasetName); err != nil {
+			return nil, fmt.Errorf("dataset file %q: %w", path, err)
+		}
+		if other, exists := files[datasetName]; exists {
+			return nil, fmt.Errorf("duplicate dataset %q: %q and %q", datasetName, other, path)
+		}
+		files[datasetName] = path
+	}
+
+	return files, nil
+}
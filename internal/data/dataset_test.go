@@ -0,0 +1,93 @@
+package data_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDatasetName(t *testing.T) {
+	t.Parallel()
+
+	t.Run("accepts path-safe names", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"jokes", "error-pages", "motd_v2", "Tips2"} {
+			assert.NoError(t, data.ValidateDatasetName(name), name)
+		}
+	})
+
+	t.Run("rejects names that are not one path segment", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"", "a/b", "a b", "..", "jokes.json"} {
+			assert.Error(t, data.ValidateDatasetName(name), name)
+		}
+	})
+
+	t.Run("rejects reserved names", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"default", "admin", "datasets", "healthz", "readyz", "metrics", "random", "daily"} {
+			assert.Error(t, data.ValidateDatasetName(name), name)
+		}
+	})
+}
+
+func TestDatasetFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lists data files by name", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "jokes.json"), []byte(`[1]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "quotes.yaml"), []byte(`- 2`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`# docs`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.json"), []byte(`[]`), 0o600))
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.json"), 0o700))
+
+		files, err := data.DatasetFiles(dir)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"jokes":  filepath.Join(dir, "jokes.json"),
+			"quotes": filepath.Join(dir, "quotes.yaml"),
+		}, files)
+	})
+
+	t.Run("returns error on invalid file name", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "my jokes.json"), []byte(`[1]`), 0o600))
+
+		_, err := data.DatasetFiles(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid dataset name")
+	})
+
+	t.Run("returns error on duplicate name", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "jokes.json"), []byte(`[1]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "jokes.csv"), []byte("a\n1\n"), 0o600))
+
+		_, err := data.DatasetFiles(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `duplicate dataset "jokes"`)
+	})
+
+	t.Run("returns error on missing dir", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := data.DatasetFiles("/does/not/exist")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "read dataset dir")
+	})
+}
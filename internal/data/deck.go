@@ -0,0 +1,93 @@
+package data
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Deck holds the shuffled order in which a Store's elements are dealt to one
+// client. Decks are safe for concurrent use.
+type Deck struct {
+	mu    sync.Mutex
+	gen   uint64 // snapshot generation the order was shuffled for
+	order []int  // remaining element indexes; dealt from the end
+	last  int    // index dealt last, -1 if none
+}
+
+// NewDeck returns an empty deck; it is shuffled on the first deal.
+func NewDeck() *Deck {
+	return &Deck{last: -1}
+}
+
+// Deal returns the next n elements of deck. Every element with a non-zero
+// weight is dealt once before any repeats, and a reshuffled deck never starts
+// with the element dealt last. The deck starts over when the store's elements
+// change. With unique set, the n elements are distinct.
+func (s *Store) Deal(rnd *rand.Rand, deck *Deck, n int, unique bool) ([]Pick, error) {
+	snap := s.current.Load()
+	if len(snap.elements) == 0 || snap.pickable == 0 {
+		return nil, ErrNoElements
+	}
+	if unique && n > snap.pickable {
+		return nil, fmt.Errorf("%w: requested %d unique elements, %d available", ErrNotEnoughElements, n, snap.pickable)
+	}
+
+	deck.mu.Lock()
+	defer deck.mu.Unlock()
+
+	if deck.gen != snap.gen {
+		deck.gen, deck.order, deck.last = snap.gen, nil, -1
+	}
+	picks := make([]Pick, 0, n)
+	for range n {
+		if len(deck.order) == 0 {
+			deck.order = snap.shuffled(rnd, deck.last)
+			if unique {
+				// Deal the elements of this request last, so it cannot
+				// repeat them while they stay in the new round.
+				deferPicked(deck.order, picks)
+			}
+		}
+		idx := deck.order[len(deck.order)-1]
+		deck.order = deck.order[:len(deck.order)-1]
+		deck.last = idx
+		picks = append(picks, snap.at(idx))
+	}
+	return picks, nil
+}
+
+// deferPicked moves the indexes of picks to the front of order, where they
+// are dealt last. The relative order of all other indexes is kept.
+func deferPicked(order []int, picks []Pick) {
+	picked := make(map[int]bool, len(picks))
+	for _, p := range picks {
+		picked[p.Index] = true
+	}
+	front := 0
+	for i, idx := range order {
+		if picked[idx] {
+			copy(order[front+1:i+1], order[front:i])
+			order[front] = idx
+			front++
+		}
+	}
+}
+
+// shuffled returns the pickable element indexes in random order, arranged so
+// that the first index dealt is not last.
+func (snap *snapshot) shuffled(rnd *rand.Rand, last int) []int {
+	order := make([]int, 0, snap.pickable)
+	for i := range snap.elements {
+		if snap.weights == nil || snap.weights[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	if n := len(order); n > 1 && order[n-1] == last {
+		j := rnd.Intn(n - 1)
+		order[n-1], order[j] = order[j], order[n-1]
+	}
+	return order
+}
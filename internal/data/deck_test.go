@@ -0,0 +1,128 @@
+package data_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deals every element once per round", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`0`), []byte(`1`), []byte(`2`), []byte(`3`), []byte(`4`)})
+		rnd := rand.New(rand.NewSource(1))
+		deck := data.NewDeck()
+
+		last := -1
+		for range 20 {
+			seen := map[int]bool{}
+			for range 5 {
+				picks, err := store.Deal(rnd, deck, 1, false)
+				require.NoError(t, err)
+				idx := picks[0].Index
+				assert.False(t, seen[idx], "element %d dealt twice in one round", idx)
+				assert.NotEqual(t, last, idx, "element %d dealt twice in a row", idx)
+				seen[idx] = true
+				last = idx
+			}
+			assert.Len(t, seen, 5)
+		}
+	})
+
+	t.Run("skips zero weights", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`{"w":0}`), []byte(`{"w":1}`), []byte(`{"w":5}`)}
+		store, err := data.NewStore(elements, data.Options{WeightPointer: "/w"})
+		require.NoError(t, err)
+
+		picks, err := store.Deal(rand.New(rand.NewSource(1)), data.NewDeck(), 10, false)
+		require.NoError(t, err)
+		for _, p := range picks {
+			assert.NotEqual(t, 0, p.Index)
+		}
+	})
+
+	t.Run("unique requests never wrap around", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`0`), []byte(`1`), []byte(`2`)})
+		rnd := rand.New(rand.NewSource(1))
+		deck := data.NewDeck()
+
+		_, err := store.Deal(rnd, deck, 2, false)
+		require.NoError(t, err)
+
+		picks, err := store.Deal(rnd, deck, 3, true)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int{0, 1, 2}, []int{picks[0].Index, picks[1].Index, picks[2].Index})
+
+		_, err = store.Deal(rnd, deck, 4, true)
+		assert.ErrorIs(t, err, data.ErrNotEnoughElements)
+	})
+
+	t.Run("unique requests deal the rest of the round first", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`0`), []byte(`1`), []byte(`2`), []byte(`3`)})
+		rnd := rand.New(rand.NewSource(1))
+		deck := data.NewDeck()
+
+		dealt := map[int]bool{}
+		picks, err := store.Deal(rnd, deck, 3, false)
+		require.NoError(t, err)
+		for _, p := range picks {
+			dealt[p.Index] = true
+		}
+
+		picks, err = store.Deal(rnd, deck, 3, true)
+		require.NoError(t, err)
+		assert.False(t, dealt[picks[0].Index], "undealt element %d discarded", picks[0].Index)
+		seen := map[int]bool{}
+		for _, p := range picks {
+			assert.False(t, seen[p.Index], "element %d repeated", p.Index)
+			seen[p.Index] = true
+		}
+
+		// The new round started with the last two picks and holds the others.
+		var want []int
+		for idx := range 4 {
+			if idx != picks[1].Index && idx != picks[2].Index {
+				want = append(want, idx)
+			}
+		}
+		rest, err := store.Deal(rnd, deck, 2, false)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, want, []int{rest[0].Index, rest[1].Index})
+	})
+
+	t.Run("starts over after reload", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)})
+		rnd := rand.New(rand.NewSource(1))
+		deck := data.NewDeck()
+
+		_, err := store.Deal(rnd, deck, 1, false)
+		require.NoError(t, err)
+		require.NoError(t, store.Replace(data.Elements{[]byte(`"x"`), []byte(`"y"`), []byte(`"z"`)}))
+
+		picks, err := store.Deal(rnd, deck, 3, true)
+		require.NoError(t, err)
+		got := []string{string(picks[0].Element), string(picks[1].Element), string(picks[2].Element)}
+		assert.ElementsMatch(t, []string{`"x"`, `"y"`, `"z"`}, got)
+	})
+
+	t.Run("empty store", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newStore(t, data.Elements{}).Deal(rand.New(rand.NewSource(1)), data.NewDeck(), 1, false)
+		assert.ErrorIs(t, err, data.ErrNoElements)
+	})
+}
@@ -0,0 +1,21 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// etag returns a strong entity tag for elem derived from its content.
+func etag(elem Element) string {
+	sum := sha256.Sum256(elem)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// buildETags returns the entity tag of every element.
+func buildETags(elements Elements) []string {
+	etags := make([]string, len(elements))
+	for i, elem := range elements {
+		etags[i] = etag(elem)
+	}
+	return etags
+}
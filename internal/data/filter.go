@@ -0,0 +1,143 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Filter restricts picks to object elements whose top-level Field equals Value.
+// For array fields it matches if any item equals Value.
+type Filter struct {
+	Field string
+	Value string
+}
+
+// ParseFilter parses a "field:value" filter expression.
+func ParseFilter(s string) (Filter, error) {
+	field, value, ok := strings.Cut(s, ":")
+	if !ok || field == "" {
+		return Filter{}, fmt.Errorf("invalid filter %q: expected FIELD:VALUE", s)
+	}
+	return Filter{Field: field, Value: value}, nil
+}
+
+// ParseFilters parses several "field:value" filter expressions.
+func ParseFilters(exprs []string) ([]Filter, error) {
+	filters := make([]Filter, 0, len(exprs))
+	for _, expr := range exprs {
+		f, err := ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// fieldIndex maps field → value → ascending element indexes.
+type fieldIndex map[string]map[string][]int
+
+// buildFieldIndex indexes the scalar top-level fields of object elements.
+// When fields is non-empty only those fields are indexed.
+func buildFieldIndex(elements Elements, fields []string) fieldIndex {
+	index := fieldIndex{}
+	for i, elem := range elements {
+		if len(elem) == 0 || elem[0] != '{' {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(elem))
+		dec.UseNumber()
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil {
+			continue
+		}
+
+		for field, value := range obj {
+			if len(fields) > 0 && !slices.Contains(fields, field) {
+				continue
+			}
+
+			values, ok := value.([]any)
+			if !ok {
+				values = []any{value}
+			}
+			for _, v := range values {
+				key, ok := filterKey(v)
+				if !ok {
+					continue
+				}
+				if index[field] == nil {
+					index[field] = map[string][]int{}
+				}
+				postings := index[field][key]
+				// Avoid duplicates when an array repeats a value.
+				if n := len(postings); n == 0 || postings[n-1] != i {
+					index[field][key] = append(postings, i)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// filterKey returns the string a scalar JSON value is matched against.
+func filterKey(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	default:
+		return "", false
+	}
+}
+
+// match returns the ascending indexes of elements matching all filters.
+func (index fieldIndex) match(filters []Filter) []int {
+	lists := make([][]int, 0, len(filters))
+	for _, f := range filters {
+		postings := index[f.Field][f.Value]
+		if len(postings) == 0 {
+			return nil
+		}
+		lists = append(lists, postings)
+	}
+
+	// Intersect starting from the shortest list to keep the work small.
+	slices.SortFunc(lists, func(a, b []int) int { return len(a) - len(b) })
+	result := lists[0]
+	for _, other := range lists[1:] {
+		result = intersectSorted(result, other)
+		if len(result) == 0 {
+			return nil
+		}
+	}
+	return result
+}
+
+// intersectSorted returns the values present in both ascending slices.
+func intersectSorted(a, b []int) []int {
+	out := make([]int, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("splits at first colon", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := ParseFilter("url:https://example.com")
+		require.NoError(t, err)
+		assert.Equal(t, Filter{Field: "url", Value: "https://example.com"}, f)
+	})
+
+	t.Run("allows empty value", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := ParseFilter("note:")
+		require.NoError(t, err)
+		assert.Equal(t, Filter{Field: "note", Value: ""}, f)
+	})
+
+	t.Run("rejects malformed filters", func(t *testing.T) {
+		t.Parallel()
+
+		for _, expr := range []string{"", "type", ":value"} {
+			_, err := ParseFilter(expr)
+			assert.Error(t, err, expr)
+		}
+
+		_, err := ParseFilters([]string{"type:a", "broken"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFieldIndex(t *testing.T) {
+	t.Parallel()
+
+	elements := Elements{
+		[]byte(`{"type":"general","tags":["pun","short","pun"],"id":1,"safe":true}`),
+		[]byte(`{"type":"programming","tags":["pun"],"id":2,"safe":false,"meta":{"x":1}}`),
+		[]byte(`{"type":"general","id":3.5,"note":null}`),
+		[]byte(`"not an object"`),
+		[]byte(`[1, 2]`),
+	}
+	index := buildFieldIndex(elements, nil)
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    []int
+	}{
+		{name: "string field", filters: []Filter{{"type", "general"}}, want: []int{0, 2}},
+		{name: "array items", filters: []Filter{{"tags", "pun"}}, want: []int{0, 1}},
+		{name: "number", filters: []Filter{{"id", "3.5"}}, want: []int{2}},
+		{name: "bool", filters: []Filter{{"safe", "false"}}, want: []int{1}},
+		{name: "anded filters", filters: []Filter{{"type", "general"}, {"tags", "pun"}}, want: []int{0}},
+		{name: "no match", filters: []Filter{{"type", "knock-knock"}}, want: nil},
+		{name: "disjoint filters", filters: []Filter{{"type", "programming"}, {"id", "1"}}, want: nil},
+		{name: "nested objects are not indexed", filters: []Filter{{"meta", `{"x":1}`}}, want: nil},
+		{name: "null is not indexed", filters: []Filter{{"note", "null"}}, want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, index.match(tc.filters))
+		})
+	}
+}
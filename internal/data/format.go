@@ -0,0 +1,239 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Format identifies the encoding of a data file.
+type Format string
+
+const (
+	FormatAuto   Format = "auto"   // Detect the format from the file extension
+	FormatJSON   Format = "json"   // JSON array
+	FormatYAML   Format = "yaml"   // YAML sequence
+	FormatNDJSON Format = "ndjson" // Newline-delimited JSON, one element per line
+	FormatCSV    Format = "csv"    // CSV with a header row; rows become objects
+)
+
+// Formats lists the formats accepted by LoadElements.
+var Formats = []Format{FormatAuto, FormatJSON, FormatYAML, FormatNDJSON, FormatCSV}
+
+// formatExtensions maps file extensions to their format.
+var formatExtensions = map[string]Format{
+	".json":   FormatJSON,
+	".yaml":   FormatYAML,
+	".yml":    FormatYAML,
+	".ndjson": FormatNDJSON,
+	".jsonl":  FormatNDJSON,
+	".csv":    FormatCSV,
+}
+
+// FormatFromPath returns the format matching the extension of path.
+// Unknown extensions are treated as JSON.
+func FormatFromPath(path string) Format {
+	if format, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	return FormatJSON
+}
+
+// resolve returns the concrete format to use for path.
+func (f Format) resolve(path string) Format {
+	if f == "" || f == FormatAuto {
+		return FormatFromPath(path)
+	}
+	return f
+}
+
+// decodeElements decodes all elements from r.
+func decodeElements(r io.Reader, format Format) (Elements, error) {
+	switch format {
+	case FormatYAML:
+		return decodeYAML(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		return decodeJSON(r)
+	}
+}
+
+// decodeJSON decodes a JSON array.
+func decodeJSON(r io.Reader) (Elements, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return Elements{}, fmt.Errorf("read data: %w", err)
+	}
+	return parseElements(content)
+}
+
+// decodeNDJSON streams newline-delimited JSON values so large files are never
+// held in memory as a whole. Only the offsets of newlines are kept to report
+// the line and column of syntax errors.
+func decodeNDJSON(r io.Reader) (Elements, error) {
+	elements := Elements{}
+	lines := &lineReader{r: r}
+	dec := json.NewDecoder(lines)
+	for {
+		var elem Element
+		err := dec.Decode(&elem)
+		if errors.Is(err, io.EOF) {
+			return elements, nil
+		}
+		if err != nil {
+			if offset, ok := errorOffset(err); ok {
+				line, column := lines.position(offset)
+				return Elements{}, fmt.Errorf("unmarshal data: element %d: line %d, column %d: %w", len(elements), line, column, err)
+			}
+			return Elements{}, fmt.Errorf("unmarshal data: element %d: %w", len(elements), err)
+		}
+		elements = append(elements, elem)
+	}
+}
+
+// decodeCSV turns every row after the header into an object keyed by the
+// header columns. All values are strings.
+func decodeCSV(r io.Reader) (Elements, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return Elements{}, nil
+	}
+	if err != nil {
+		return Elements{}, fmt.Errorf("unmarshal data: %w", err)
+	}
+
+	keys := make([][]byte, len(header))
+	for i, name := range header {
+		if keys[i], err = json.Marshal(name); err != nil {
+			return Elements{}, fmt.Errorf("unmarshal data: %w", err)
+		}
+	}
+
+	elements := Elements{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return elements, nil
+		}
+		if err != nil {
+			return Elements{}, fmt.Errorf("unmarshal data: %w", err)
+		}
+
+		// Build the object by hand to keep the column order.
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, value := range record {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return Elements{}, fmt.Errorf("unmarshal data: %w", err)
+			}
+			buf.Write(encoded)
+		}
+		buf.WriteByte('}')
+
+		elements = append(elements, Element(buf.Bytes()))
+	}
+}
+
+// decodeYAML decodes a YAML sequence. Elements are converted to JSON with
+// mapping keys kept in document order.
+func decodeYAML(r io.Reader) (Elements, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Elements{}, nil
+		}
+		return Elements{}, fmt.Errorf("unmarshal data: %w", err)
+	}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.SequenceNode {
+		return Elements{}, fmt.Errorf("unmarshal data: line %d: expected a YAML sequence", root.Line)
+	}
+
+	elements := make(Elements, 0, len(root.Content))
+	for _, node := range root.Content {
+		var buf bytes.Buffer
+		if err := writeYAMLNodeJSON(&buf, node); err != nil {
+			return Elements{}, fmt.Errorf("unmarshal data: element %d: %w", len(elements), err)
+		}
+		elements = append(elements, Element(buf.Bytes()))
+	}
+
+	return elements, nil
+}
+
+// writeYAMLNodeJSON writes node as JSON to buf.
+func writeYAMLNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeYAMLNodeJSON(buf, node.Alias)
+
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeYAMLNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLNodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+
+	case yaml.ScalarNode:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		buf.Write(encoded)
+		return nil
+
+	default:
+		return fmt.Errorf("line %d: unsupported YAML node", node.Line)
+	}
+}
@@ -0,0 +1,145 @@
+package data_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]data.Format{
+		"jokes.json":    data.FormatJSON,
+		"quotes.yaml":   data.FormatYAML,
+		"quotes.YML":    data.FormatYAML,
+		"big.ndjson":    data.FormatNDJSON,
+		"big.jsonl":     data.FormatNDJSON,
+		"export.csv":    data.FormatCSV,
+		"data":          data.FormatJSON,
+		"/app/data.txt": data.FormatJSON,
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, data.FormatFromPath(path), path)
+	}
+}
+
+func TestLoadElementsFormats(t *testing.T) {
+	t.Parallel()
+
+	// write stores content in a temp file named name.
+	write := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("yaml sequence keeps key order", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "quotes.yaml", `
+- setup: "Why?"
+  punchline: Because.
+  weight: 3
+  tags: [a, b]
+- plain string
+- 42
+- anchor: &a {x: 1}
+  ref: *a
+`)
+
+		elements, err := data.LoadElements(path, data.FormatAuto)
+		require.NoError(t, err)
+		require.Len(t, elements, 4)
+
+		assert.Equal(t, `{"setup":"Why?","punchline":"Because.","weight":3,"tags":["a","b"]}`, string(elements[0]))
+		assert.Equal(t, `"plain string"`, string(elements[1]))
+		assert.Equal(t, `42`, string(elements[2]))
+		assert.Equal(t, `{"anchor":{"x":1},"ref":{"x":1}}`, string(elements[3]))
+	})
+
+	t.Run("yaml that is not a sequence", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "quotes.yaml", "a: 1\n")
+
+		_, err := data.LoadElements(path, data.FormatAuto)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected a YAML sequence")
+	})
+
+	t.Run("ndjson one element per line", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "big.ndjson", "{\"msg\":\"a\"}\n\"b\"\n\n3\n")
+
+		elements, err := data.LoadElements(path, data.FormatAuto)
+		require.NoError(t, err)
+		require.Len(t, elements, 3)
+
+		assert.Equal(t, `{"msg":"a"}`, string(elements[0]))
+		assert.Equal(t, `"b"`, string(elements[1]))
+		assert.Equal(t, `3`, string(elements[2]))
+	})
+
+	t.Run("ndjson reports broken element", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "big.ndjson", "{\"msg\":\"a\"}\n{broken\n")
+
+		_, err := data.LoadElements(path, data.FormatAuto)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unmarshal data: element 1: line 2, column 2: ")
+	})
+
+	t.Run("csv rows become objects", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "export.csv", "type,setup,punchline\ngeneral,\"Why, though?\",Because.\nprogramming,Bug?,Feature.\n")
+
+		elements, err := data.LoadElements(path, data.FormatAuto)
+		require.NoError(t, err)
+		require.Len(t, elements, 2)
+
+		assert.Equal(t, `{"type":"general","setup":"Why, though?","punchline":"Because."}`, string(elements[0]))
+		assert.Equal(t, `{"type":"programming","setup":"Bug?","punchline":"Feature."}`, string(elements[1]))
+	})
+
+	t.Run("csv with wrong field count", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "export.csv", "a,b\n1,2,3\n")
+
+		_, err := data.LoadElements(path, data.FormatAuto)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unmarshal data")
+	})
+
+	t.Run("explicit format overrides extension", func(t *testing.T) {
+		t.Parallel()
+
+		path := write(t, "data.txt", "- a\n- b\n")
+
+		elements, err := data.LoadElements(path, data.FormatYAML)
+		require.NoError(t, err)
+		assert.Len(t, elements, 2)
+
+		_, err = data.LoadElements(path, data.FormatAuto)
+		assert.Error(t, err, "unknown extensions default to JSON")
+	})
+
+	t.Run("empty files yield no elements", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"empty.yaml", "empty.ndjson", "empty.csv"} {
+			elements, err := data.LoadElements(write(t, name, ""), data.FormatAuto)
+			require.NoError(t, err, name)
+			assert.Empty(t, elements, name)
+		}
+	})
+}
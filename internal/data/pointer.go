@@ -0,0 +1,53 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookupPointer resolves a JSON pointer (RFC 6901) inside raw.
+// It reports false when any reference token does not exist.
+func lookupPointer(raw json.RawMessage, pointer string) (json.RawMessage, bool) {
+	if pointer == "" {
+		return raw, true
+	}
+
+	current := raw
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(current, &obj); err == nil {
+			next, ok := obj[token]
+			if !ok {
+				return nil, false
+			}
+			current = next
+			continue
+		}
+
+		var arr []json.RawMessage
+		if err := json.Unmarshal(current, &arr); err == nil {
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			current = arr[idx]
+			continue
+		}
+
+		return nil, false
+	}
+
+	return current, true
+}
+
+// ValidatePointer reports whether pointer is a syntactically valid JSON pointer.
+func ValidatePointer(pointer string) error {
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", pointer)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupPointer(t *testing.T) {
+	t.Parallel()
+
+	doc := []byte(`{"weight":3,"meta":{"tags":["a","b"],"a/b":1,"m~n":2}}`)
+
+	tests := []struct {
+		pointer string
+		want    string
+		found   bool
+	}{
+		{pointer: "", want: string(doc), found: true},
+		{pointer: "/weight", want: `3`, found: true},
+		{pointer: "/meta/tags/1", want: `"b"`, found: true},
+		{pointer: "/meta/a~1b", want: `1`, found: true},
+		{pointer: "/meta/m~0n", want: `2`, found: true},
+		{pointer: "/missing", found: false},
+		{pointer: "/meta/tags/9", found: false},
+		{pointer: "/weight/deeper", found: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pointer, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := lookupPointer(doc, tc.pointer)
+			assert.Equal(t, tc.found, ok)
+			if tc.found {
+				assert.JSONEq(t, tc.want, string(got))
+			}
+		})
+	}
+}
+
+func TestValidatePointer(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidatePointer(""))
+	assert.NoError(t, ValidatePointer("/weight"))
+	assert.Error(t, ValidatePointer("weight"))
+}
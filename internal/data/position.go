@@ -0,0 +1,62 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+// errorOffset returns the input offset a JSON decoding error refers to.
+func errorOffset(err error) (int64, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset, true
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Offset, true
+	}
+	return 0, false
+}
+
+// position returns the 1-based line and column of the byte before offset in
+// content; JSON errors report the offset after the offending byte.
+func position(content []byte, offset int64) (line, column int) {
+	idx := int(max(min(offset, int64(len(content)))-1, 0))
+	line = bytes.Count(content[:idx], []byte{'\n'}) + 1
+	column = idx - bytes.LastIndexByte(content[:idx], '\n')
+	return line, column
+}
+
+// lineReader records the offsets of the newlines read through it, so
+// positions can be reported without keeping the content.
+type lineReader struct {
+	r        io.Reader
+	read     int64
+	newlines []int64
+}
+
+// Read reads from the underlying reader and records newlines.
+func (lr *lineReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			lr.newlines = append(lr.newlines, lr.read+int64(i))
+		}
+	}
+	lr.read += int64(n)
+	return n, err
+}
+
+// position returns the 1-based line and column of the byte before offset.
+func (lr *lineReader) position(offset int64) (line, column int) {
+	idx := max(offset-1, 0)
+	before := sort.Search(len(lr.newlines), func(i int) bool { return lr.newlines[i] >= idx })
+	start := int64(0)
+	if before > 0 {
+		start = lr.newlines[before-1] + 1
+	}
+	return before + 1, int(idx-start) + 1
+}
@@ -0,0 +1,144 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotModified is returned by Remote.Fetch when the server reports that the
+// data did not change since the last successful fetch.
+var ErrNotModified = errors.New("data not modified")
+
+// IsURL reports whether path refers to an HTTP(S) source instead of a file.
+func IsURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// Validators identify fetched content in conditional requests.
+type Validators struct {
+	ETag         string // ETag response header
+	LastModified string // Last-Modified response header
+}
+
+// Remote fetches elements from an HTTP(S) URL using conditional requests.
+type Remote struct {
+	url        string
+	format     Format
+	client     *http.Client
+	validators Validators // validators of the content being served
+}
+
+// NewRemote returns a Remote for rawURL. With FormatAuto the format is
+// detected from the extension of the URL path.
+func NewRemote(rawURL string, format Format, client *http.Client) *Remote {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	return &Remote{
+		url:    rawURL,
+		format: format.resolve(path),
+		client: client,
+	}
+}
+
+// Fetch downloads and decodes the elements and returns the validators of the
+// fetched content. Once validators are accepted, it sends If-None-Match /
+// If-Modified-Since and returns ErrNotModified when the server answers 304.
+func (r *Remote) Fetch(ctx context.Context) (Elements, Validators, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return Elements{}, Validators{}, fmt.Errorf("fetch data: %w", err)
+	}
+	if r.validators.ETag != "" {
+		req.Header.Set("If-None-Match", r.validators.ETag)
+	}
+	if r.validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", r.validators.LastModified)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return Elements{}, Validators{}, fmt.Errorf("fetch data: %w", err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return Elements{}, Validators{}, ErrNotModified
+	default:
+		return Elements{}, Validators{}, fmt.Errorf("fetch data: unexpected status %q from %s", resp.Status, r.url)
+	}
+
+	elements, err := decodeElements(resp.Body, r.format)
+	if err != nil {
+		return Elements{}, Validators{}, err
+	}
+
+	return elements, Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// Accept records v as the validators of the content being served. It must
+// only be called once the fetched elements were loaded, so content that
+// failed to load is downloaded again instead of answered with 304.
+func (r *Remote) Accept(v Validators) {
+	r.validators = v
+}
+
+// Refresh re-fetches the elements every interval and swaps changed ones into
+// store until ctx is canceled. The previous elements are kept on failure.
+func (r *Remote) Refresh(ctx context.Context, store *Store, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh(ctx, store, logger)
+		}
+	}
+}
+
+// refresh performs one conditional fetch and updates store.
+func (r *Remote) refresh(ctx context.Context, store *Store, logger *slog.Logger) {
+	elements, validators, err := r.Fetch(ctx)
+	if errors.Is(err, ErrNotModified) {
+		store.MarkReloaded()
+		logger.Debug("data not modified", "url", r.url)
+		return
+	}
+	if err == nil && len(elements) == 0 {
+		err = ErrNoElements
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			store.MarkReloadFailed()
+			logger.Error("refresh data, keeping previous elements", "url", r.url, "error", err)
+		}
+		return
+	}
+
+	if err := store.Replace(elements); err != nil {
+		store.MarkReloadFailed()
+		logger.Error("refresh data, keeping previous elements", "url", r.url, "error", err)
+		return
+	}
+	r.Accept(validators)
+	if skipped := store.Skipped(); skipped != nil {
+		logger.Warn("skipped invalid elements", "url", r.url, "indexes", skipped.Indexes(), "error", skipped)
+	}
+	logger.Info("refreshed data", "url", r.url, "count", len(store.Elements()))
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contentServer serves body with an ETag derived from its version and counts
+// full and conditional responses.
+type contentServer struct {
+	mu          sync.Mutex
+	body        string
+	status      int
+	version     int
+	full        int
+	notModified int
+	conditions  []string // If-None-Match of every request
+}
+
+func (s *contentServer) set(body string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.status = body, status
+	s.version++
+}
+
+func (s *contentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag := `"v` + strings.Repeat("1", s.version) + `"`
+	s.conditions = append(s.conditions, r.Header.Get("If-None-Match"))
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full++
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(s.status)
+	_, _ = w.Write([]byte(s.body))
+}
+
+func TestIsURL(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsURL("http://example.com/data.json"))
+	assert.True(t, IsURL("HTTPS://example.com/data.json"))
+	assert.False(t, IsURL("/app/data.json"))
+	assert.False(t, IsURL("data.json"))
+}
+
+func TestRemote(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	t.Run("fetch uses conditional requests", func(t *testing.T) {
+		t.Parallel()
+
+		content := &contentServer{}
+		content.set(`[1, 2]`, http.StatusOK)
+		srv := httptest.NewServer(content)
+		defer srv.Close()
+
+		remote := NewRemote(srv.URL+"/data.json", FormatAuto, srv.Client())
+
+		elements, validators, err := remote.Fetch(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, elements, 2)
+		assert.Equal(t, `"v1"`, validators.ETag)
+
+		// Validators are only sent once accepted.
+		_, _, err = remote.Fetch(t.Context())
+		require.NoError(t, err)
+
+		remote.Accept(validators)
+		_, _, err = remote.Fetch(t.Context())
+		assert.ErrorIs(t, err, ErrNotModified)
+
+		assert.Equal(t, 2, content.full)
+		assert.Equal(t, 1, content.notModified)
+	})
+
+	t.Run("format detected from url path", func(t *testing.T) {
+		t.Parallel()
+
+		content := &contentServer{}
+		content.set("- a\n- b\n- c\n", http.StatusOK)
+		srv := httptest.NewServer(content)
+		defer srv.Close()
+
+		remote := NewRemote(srv.URL+"/quotes.yaml?token=abc", FormatAuto, srv.Client())
+
+		elements, _, err := remote.Fetch(t.Context())
+		require.NoError(t, err)
+		assert.Len(t, elements, 3)
+	})
+
+	t.Run("fetch reports unexpected status", func(t *testing.T) {
+		t.Parallel()
+
+		content := &contentServer{}
+		content.set(`oops`, http.StatusInternalServerError)
+		srv := httptest.NewServer(content)
+		defer srv.Close()
+
+		_, _, err := NewRemote(srv.URL, FormatAuto, srv.Client()).Fetch(t.Context())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected status")
+	})
+
+	t.Run("refresh swaps changed data and keeps last good set", func(t *testing.T) {
+		t.Parallel()
+
+		content := &contentServer{}
+		content.set(`[1]`, http.StatusOK)
+		srv := httptest.NewServer(content)
+		defer srv.Close()
+
+		remote := NewRemote(srv.URL, FormatAuto, srv.Client())
+		elements, validators, err := remote.Fetch(t.Context())
+		require.NoError(t, err)
+		store := newTestStore(t, elements)
+		remote.Accept(validators)
+
+		// Unchanged: 304, nothing replaced.
+		before := store.LoadedAt()
+		remote.refresh(t.Context(), store, logger)
+		assert.Equal(t, before, store.LoadedAt())
+
+		// Changed: replaced.
+		content.set(`[1, 2, 3]`, http.StatusOK)
+		remote.refresh(t.Context(), store, logger)
+		assert.Len(t, store.Elements(), 3)
+
+		// Server error, invalid and empty data keep the last good set.
+		content.set(`boom`, http.StatusBadGateway)
+		remote.refresh(t.Context(), store, logger)
+		content.set(`{ not json`, http.StatusOK)
+		remote.refresh(t.Context(), store, logger)
+		content.set(`[]`, http.StatusOK)
+		remote.refresh(t.Context(), store, logger)
+		assert.Len(t, store.Elements(), 3)
+	})
+
+	t.Run("refresh downloads content that failed to load again", func(t *testing.T) {
+		t.Parallel()
+
+		content := &contentServer{}
+		content.set(`[1]`, http.StatusOK)
+		srv := httptest.NewServer(content)
+		defer srv.Close()
+
+		remote := NewRemote(srv.URL, FormatAuto, srv.Client())
+		elements, validators, err := remote.Fetch(t.Context())
+		require.NoError(t, err)
+		store := newTestStore(t, elements)
+		remote.Accept(validators)
+
+		// Content that decodes but cannot be served, with an ETag of its own.
+		content.set(`[]`, http.StatusOK)
+		remote.refresh(t.Context(), store, logger)
+		remote.refresh(t.Context(), store, logger)
+
+		assert.Equal(t, []string{"", `"v1"`, `"v1"`}, content.conditions)
+		assert.Equal(t, 3, content.full)
+		assert.Zero(t, content.notModified)
+		assert.Equal(t, uint64(2), store.ConsecutiveReloadFailures())
+		assert.Len(t, store.Elements(), 1)
+	})
+}
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// aliasSampler draws weighted indexes in O(1) using Vose's alias method.
+type aliasSampler struct {
+	prob  []float64
+	alias []int
+}
+
+// newAliasSampler builds the probability and alias tables for weights.
+func newAliasSampler(weights []float64) (*aliasSampler, error) {
+	n := len(weights)
+	if n == 0 {
+		return nil, errors.New("no weights")
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return nil, errors.New("weights must not all be zero")
+	}
+
+	s := &aliasSampler{
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+
+	// Scale weights so the average is 1, then pair each under-full slot with
+	// an over-full one.
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		l := small[len(small)-1]
+		small = small[:len(small)-1]
+		g := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		s.prob[l] = scaled[l]
+		s.alias[l] = g
+
+		scaled[g] = scaled[g] + scaled[l] - 1
+		if scaled[g] < 1 {
+			small = append(small, g)
+		} else {
+			large = append(large, g)
+		}
+	}
+
+	// Remaining slots are full up to floating point error.
+	for _, i := range large {
+		s.prob[i] = 1
+	}
+	for _, i := range small {
+		s.prob[i] = 1
+	}
+
+	return s, nil
+}
+
+// sample returns a weighted random index.
+func (s *aliasSampler) sample(rnd *rand.Rand) int {
+	i := rnd.Intn(len(s.prob))
+	if rnd.Float64() < s.prob[i] {
+		return i
+	}
+	return s.alias[i]
+}
+
+// sampleUnique returns n distinct indexes in [0, m) using a partial
+// Fisher-Yates shuffle that only tracks swapped positions.
+func sampleUnique(rnd *rand.Rand, m, n int) []int {
+	swapped := make(map[int]int, n)
+	picked := make([]int, n)
+	for i := range n {
+		j := i + rnd.Intn(m-i)
+
+		vj, ok := swapped[j]
+		if !ok {
+			vj = j
+		}
+		vi, ok := swapped[i]
+		if !ok {
+			vi = i
+		}
+
+		swapped[j] = vi
+		picked[i] = vj
+	}
+	return picked
+}
+
+// weightedSampleUnique returns n distinct indexes drawn proportionally to
+// weights without replacement (Efraimidis-Spirakis). Indexes with weight 0
+// are never drawn; callers must ensure at least n weights are positive.
+func weightedSampleUnique(rnd *rand.Rand, weights []float64, n int) []int {
+	type keyed struct {
+		idx int
+		key float64
+	}
+
+	keys := make([]keyed, 0, len(weights))
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		// log(u)/w orders like u^(1/w) but avoids underflow for small weights.
+		keys = append(keys, keyed{idx: i, key: math.Log(1-rnd.Float64()) / w})
+	}
+	slices.SortFunc(keys, func(a, b keyed) int { return cmp.Compare(b.key, a.key) })
+
+	picked := make([]int, n)
+	for i := range n {
+		picked[i] = keys[i].idx
+	}
+	return picked
+}
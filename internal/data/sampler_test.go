@@ -0,0 +1,85 @@
+package data
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliasSampler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("distribution follows weights", func(t *testing.T) {
+		t.Parallel()
+
+		weights := []float64{1, 2, 3, 4}
+		s, err := newAliasSampler(weights)
+		require.NoError(t, err)
+
+		const draws = 100000
+		counts := make([]int, len(weights))
+		rnd := rand.New(rand.NewSource(7))
+		for range draws {
+			counts[s.sample(rnd)]++
+		}
+
+		for i, w := range weights {
+			assert.InDelta(t, draws*w/10, counts[i], draws*0.01, "index %d", i)
+		}
+	})
+
+	t.Run("zero weight is never drawn", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := newAliasSampler([]float64{0, 5, 0})
+		require.NoError(t, err)
+
+		rnd := rand.New(rand.NewSource(1))
+		for range 1000 {
+			assert.Equal(t, 1, s.sample(rnd))
+		}
+	})
+
+	t.Run("rejects empty and all-zero weights", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newAliasSampler(nil)
+		assert.Error(t, err)
+
+		_, err = newAliasSampler([]float64{0, 0})
+		assert.Error(t, err)
+	})
+}
+
+func TestSampleUnique(t *testing.T) {
+	t.Parallel()
+
+	t.Run("uniform picks are distinct and in range", func(t *testing.T) {
+		t.Parallel()
+
+		rnd := rand.New(rand.NewSource(3))
+		for range 100 {
+			picked := sampleUnique(rnd, 10, 10)
+			assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, picked)
+		}
+	})
+
+	t.Run("weighted picks favour heavy indexes", func(t *testing.T) {
+		t.Parallel()
+
+		weights := []float64{1, 0, 100}
+		rnd := rand.New(rand.NewSource(5))
+
+		firsts := 0
+		for range 1000 {
+			picked := weightedSampleUnique(rnd, weights, 2)
+			assert.ElementsMatch(t, []int{0, 2}, picked)
+			if picked[0] == 2 {
+				firsts++
+			}
+		}
+		assert.Greater(t, firsts, 950)
+	})
+}
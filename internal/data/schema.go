@@ -0,0 +1,132 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaPrinter formats schema violation messages.
+var schemaPrinter = message.NewPrinter(language.English)
+
+// Schema validates elements against a JSON Schema.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// LoadSchema compiles the JSON Schema at path.
+func LoadSchema(path string) (*Schema, error) {
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load schema: %w", err)
+	}
+	return &Schema{schema: schema}, nil
+}
+
+// Violation is one place where an element does not match the schema.
+type Violation struct {
+	Index   int    // Index of the element in the loaded set
+	Pointer string // JSON pointer into the data file (e.g. /3/author)
+	Message string // What the schema expected
+}
+
+// String returns the violation as "pointer: message".
+func (v Violation) String() string {
+	return v.Pointer + ": " + v.Message
+}
+
+// SchemaError lists every violation found in an element set.
+type SchemaError struct {
+	Violations []Violation
+}
+
+// Error lists the number of invalid elements and all violations.
+func (e *SchemaError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	count := len(e.Indexes())
+	if count == 1 {
+		return "1 element does not match the schema: " + strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("%d elements do not match the schema: %s", count, strings.Join(msgs, "; "))
+}
+
+// Indexes returns the sorted indexes of the invalid elements.
+func (e *SchemaError) Indexes() []int {
+	var indexes []int
+	for _, v := range e.Violations {
+		if len(indexes) == 0 || indexes[len(indexes)-1] != v.Index {
+			indexes = append(indexes, v.Index)
+		}
+	}
+	return indexes
+}
+
+// Validate checks every element and returns a *SchemaError listing all
+// violations, or nil when all elements are valid.
+func (s *Schema) Validate(elements Elements) error {
+	var violations []Violation
+	for i, elem := range elements {
+		violations = append(violations, s.violations(i, elem)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &SchemaError{Violations: violations}
+}
+
+// violations returns the violations of the element at idx.
+func (s *Schema) violations(idx int, elem Element) []Violation {
+	base := "/" + strconv.Itoa(idx)
+
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(elem))
+	if err != nil {
+		return []Violation{{Index: idx, Pointer: base, Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	err = s.schema.Validate(value)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		if err != nil {
+			return []Violation{{Index: idx, Pointer: base, Message: err.Error()}}
+		}
+		return nil
+	}
+
+	var violations []Violation
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, Violation{
+				Index:   idx,
+				Pointer: base + pointer(e.InstanceLocation),
+				Message: e.ErrorKind.LocalizedString(schemaPrinter),
+			})
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(verr)
+	return violations
+}
+
+// pointer joins reference tokens into a JSON pointer.
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escape.Replace(token))
+	}
+	return sb.String()
+}
@@ -0,0 +1,141 @@
+package data_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jokeSchema requires objects with a string "text" and an optional string
+// "author".
+const jokeSchema = `{
+	"type": "object",
+	"required": ["text"],
+	"properties": {
+		"text": {"type": "string"},
+		"author": {"type": "string"}
+	}
+}`
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid elements", func(t *testing.T) {
+		t.Parallel()
+
+		schema := writeSchema(t, jokeSchema)
+
+		assert.NoError(t, schema.Validate(data.Elements{[]byte(`{"text":"a"}`), []byte(`{"text":"b","author":"x"}`)}))
+	})
+
+	t.Run("reports every violation with pointers", func(t *testing.T) {
+		t.Parallel()
+
+		schema := writeSchema(t, jokeSchema)
+
+		err := schema.Validate(data.Elements{
+			[]byte(`{"text":"a"}`),
+			[]byte(`{"author":1}`),
+			[]byte(`{"text":"c"}`),
+			[]byte(`"d"`),
+		})
+
+		var schemaErr *data.SchemaError
+		require.ErrorAs(t, err, &schemaErr)
+		assert.Equal(t, []int{1, 3}, schemaErr.Indexes())
+
+		pointers := make([]string, 0, len(schemaErr.Violations))
+		for _, v := range schemaErr.Violations {
+			pointers = append(pointers, v.Pointer)
+		}
+		assert.ElementsMatch(t, []string{"/1", "/1/author", "/3"}, pointers)
+		assert.ErrorContains(t, err, "2 elements do not match the schema: ")
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "schema.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"type": 5}`), 0o600))
+
+		_, err := data.LoadSchema(path)
+		assert.ErrorContains(t, err, "load schema:")
+	})
+
+	t.Run("missing schema", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := data.LoadSchema(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorContains(t, err, "load schema:")
+	})
+}
+
+func TestStoreSchema(t *testing.T) {
+	t.Parallel()
+
+	valid := []byte(`{"text":"a"}`)
+	invalid := []byte(`{"text":1}`)
+
+	t.Run("fail rejects invalid sets", func(t *testing.T) {
+		t.Parallel()
+
+		opts := data.Options{Schema: writeSchema(t, jokeSchema)}
+		_, err := data.NewStore(data.Elements{valid, invalid}, opts)
+		var schemaErr *data.SchemaError
+		require.ErrorAs(t, err, &schemaErr)
+		assert.Equal(t, []int{1}, schemaErr.Indexes())
+
+		store, err := data.NewStore(data.Elements{valid}, opts)
+		require.NoError(t, err)
+		require.Error(t, store.Replace(data.Elements{invalid}))
+		assert.Equal(t, data.Elements{valid}, store.Elements())
+		assert.Nil(t, store.Skipped())
+	})
+
+	t.Run("skip drops invalid elements", func(t *testing.T) {
+		t.Parallel()
+
+		opts := data.Options{Schema: writeSchema(t, jokeSchema), SkipInvalid: true}
+		store, err := data.NewStore(data.Elements{invalid, valid, invalid}, opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, data.Elements{valid}, store.Elements())
+		require.NotNil(t, store.Skipped())
+		assert.Equal(t, []int{0, 2}, store.Skipped().Indexes())
+
+		require.NoError(t, store.Replace(data.Elements{valid, valid}))
+		assert.Nil(t, store.Skipped())
+
+		assert.ErrorContains(t, store.Replace(data.Elements{invalid}), "no valid elements")
+		assert.Len(t, store.Elements(), 2)
+	})
+
+	t.Run("edits must match", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := data.NewStore(data.Elements{valid}, data.Options{Schema: writeSchema(t, jokeSchema), SkipInvalid: true})
+		require.NoError(t, err)
+
+		_, err = store.Append(invalid)
+		require.ErrorIs(t, err, data.ErrInvalidElement)
+		assert.ErrorContains(t, err, "/1/text: ")
+
+		require.ErrorIs(t, store.Update(0, invalid), data.ErrInvalidElement)
+		assert.Equal(t, data.Elements{valid}, store.Elements())
+	})
+}
+
+// writeSchema writes schema to a temporary file and loads it.
+func writeSchema(t *testing.T, schema string) *data.Schema {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(schema), 0o600))
+	s, err := data.LoadSchema(path)
+	require.NoError(t, err)
+	return s
+}
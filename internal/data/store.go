@@ -0,0 +1,504 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/compress"
+)
+
+// Options controls the lookup structures built whenever elements are loaded
+// and where edits are persisted.
+type Options struct {
+	WeightPointer string   // JSON pointer to a numeric weight inside object elements
+	WeightsPath   string   // Sidecar JSON array with one weight per element
+	FilterFields  []string // Top-level fields indexed for filtering (empty = all)
+	PersistPath   string   // File that edits are written back to ("" = keep edits in memory)
+	PersistFormat Format   // Format used when writing PersistPath
+
+	CompressMinSize int // Elements at least this many bytes are pre-compressed (0 = disabled)
+
+	Schema      *Schema // Validates loaded and edited elements (nil = no validation)
+	SkipInvalid bool    // Drop loaded elements violating Schema instead of rejecting the set
+}
+
+// ErrNoElements is returned when a store holds no elements.
+var ErrNoElements = errors.New("no elements available")
+
+// ErrNoMatch is returned when no element matches the requested filters.
+var ErrNoMatch = errors.New("no elements match filter")
+
+// ErrNotEnoughElements is returned when more unique elements are requested
+// than can be picked.
+var ErrNotEnoughElements = errors.New("not enough elements available")
+
+// ErrIndexOutOfRange is returned when an edit targets a missing element.
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// ErrLastElement is returned when an edit would leave the store empty.
+var ErrLastElement = errors.New("cannot delete the last element")
+
+// ErrInvalidElement is returned when an edit produces an element set whose
+// lookup structures cannot be built (e.g. an invalid weight).
+var ErrInvalidElement = errors.New("invalid element")
+
+// Pick is a selected element together with its index and entity tag.
+type Pick struct {
+	Index    int
+	Element  Element
+	ETag     string            // strong entity tag of Element, including quotes
+	Variants compress.Variants // pre-compressed copies of Element (nil = none)
+}
+
+// snapshot is one immutable generation of loaded elements.
+type snapshot struct {
+	elements Elements
+	etags    []string            // strong entity tag per element
+	variants []compress.Variants // pre-compressed elements (nil = compression disabled)
+	weights  []float64           // nil selects uniformly
+	sampler  *aliasSampler       // nil selects uniformly
+	pickable int                 // number of elements with a non-zero weight
+	index    fieldIndex          // inverted indexes for filtering
+	loadedAt time.Time
+	gen      uint64       // increases with every stored snapshot
+	skipped  *SchemaError // violations of the elements dropped on load (nil = none)
+}
+
+// at returns the element at idx as a Pick.
+func (snap *snapshot) at(idx int) Pick {
+	p := Pick{Index: idx, Element: snap.elements[idx], ETag: snap.etags[idx]}
+	if snap.variants != nil {
+		p.Variants = snap.variants[idx]
+	}
+	return p
+}
+
+// pick returns one random element of a non-empty snapshot.
+func (snap *snapshot) pick(rnd *rand.Rand) Pick {
+	if snap.sampler != nil {
+		return snap.at(snap.sampler.sample(rnd))
+	}
+	return snap.at(rnd.Intn(len(snap.elements)))
+}
+
+// pickFrom returns one random element among the candidate indexes.
+// It reports false when no candidate can be picked.
+func (snap *snapshot) pickFrom(rnd *rand.Rand, candidates []int) (Pick, bool) {
+	if len(candidates) == 0 {
+		return Pick{}, false
+	}
+	if snap.weights == nil {
+		return snap.at(candidates[rnd.Intn(len(candidates))]), true
+	}
+
+	var total float64
+	for _, idx := range candidates {
+		total += snap.weights[idx]
+	}
+	if total <= 0 {
+		return Pick{}, false
+	}
+
+	target := rnd.Float64() * total
+	for _, idx := range candidates {
+		target -= snap.weights[idx]
+		if target < 0 {
+			return snap.at(idx), true
+		}
+	}
+	// Floating point rounding: fall back to the last candidate with weight.
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if snap.weights[candidates[i]] > 0 {
+			return snap.at(candidates[i]), true
+		}
+	}
+	return Pick{}, false
+}
+
+// sampleUnique returns n distinct element indexes, restricted to candidates
+// when they are non-nil.
+func (snap *snapshot) sampleUnique(rnd *rand.Rand, n int, candidates []int) ([]int, error) {
+	if candidates == nil {
+		if n > snap.pickable {
+			return nil, fmt.Errorf("%w: requested %d unique elements, %d available", ErrNotEnoughElements, n, snap.pickable)
+		}
+		if snap.weights != nil {
+			return weightedSampleUnique(rnd, snap.weights, n), nil
+		}
+		return sampleUnique(rnd, len(snap.elements), n), nil
+	}
+
+	if snap.weights == nil {
+		if n > len(candidates) {
+			return nil, fmt.Errorf("%w: requested %d unique elements, %d available", ErrNotEnoughElements, n, len(candidates))
+		}
+		idxs := sampleUnique(rnd, len(candidates), n)
+		for i, idx := range idxs {
+			idxs[i] = candidates[idx]
+		}
+		return idxs, nil
+	}
+
+	weights := make([]float64, len(candidates))
+	pickable := 0
+	for i, idx := range candidates {
+		weights[i] = snap.weights[idx]
+		if weights[i] > 0 {
+			pickable++
+		}
+	}
+	if n > pickable {
+		return nil, fmt.Errorf("%w: requested %d unique elements, %d available", ErrNotEnoughElements, n, pickable)
+	}
+	idxs := weightedSampleUnique(rnd, weights, n)
+	for i, idx := range idxs {
+		idxs[i] = candidates[idx]
+	}
+	return idxs, nil
+}
+
+// Store holds the element set currently served and allows it to be swapped
+// atomically while requests are in flight.
+type Store struct {
+	opts           Options
+	mu             sync.Mutex // serializes writers; readers only load current
+	current        atomic.Pointer[snapshot]
+	reloadFailures atomic.Uint64 // total failed reloads
+	failedReloads  atomic.Uint64 // failed reloads since the last successful one
+	generations    atomic.Uint64 // last snapshot generation
+}
+
+// NewStore returns a Store serving the given elements.
+func NewStore(elements Elements, opts Options) (*Store, error) {
+	s := &Store{opts: opts}
+	if err := s.Replace(elements); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Elements returns the element set currently served.
+func (s *Store) Elements() Elements {
+	return s.current.Load().elements
+}
+
+// LoadedAt returns the time the current element set was stored.
+func (s *Store) LoadedAt() time.Time {
+	return s.current.Load().loadedAt
+}
+
+// Skipped returns the schema violations of the elements dropped when the
+// current element set was loaded, or nil when none were dropped.
+func (s *Store) Skipped() *SchemaError {
+	return s.current.Load().skipped
+}
+
+// MarkReloadFailed records a failed attempt to reload the store's source.
+func (s *Store) MarkReloadFailed() {
+	s.reloadFailures.Add(1)
+	s.failedReloads.Add(1)
+}
+
+// MarkReloaded records a successful reload that did not need to replace the
+// elements, e.g. because the source was not modified.
+func (s *Store) MarkReloaded() {
+	s.failedReloads.Store(0)
+}
+
+// ReloadFailures returns the number of failed reload attempts.
+func (s *Store) ReloadFailures() uint64 {
+	return s.reloadFailures.Load()
+}
+
+// ConsecutiveReloadFailures returns the number of failed reload attempts
+// since the last successful one.
+func (s *Store) ConsecutiveReloadFailures() uint64 {
+	return s.failedReloads.Load()
+}
+
+// Index returns the element at idx.
+func (s *Store) Index(idx int) (Pick, error) {
+	snap := s.current.Load()
+	if len(snap.elements) == 0 {
+		return Pick{}, ErrNoElements
+	}
+	if idx < 0 || idx >= len(snap.elements) {
+		return Pick{}, ErrIndexOutOfRange
+	}
+	return snap.at(idx), nil
+}
+
+// Random returns a random element matching all filters, honouring configured
+// weights.
+func (s *Store) Random(rnd *rand.Rand, filters ...Filter) (Pick, error) {
+	snap := s.current.Load()
+	if len(snap.elements) == 0 {
+		return Pick{}, ErrNoElements
+	}
+	if len(filters) == 0 {
+		return snap.pick(rnd), nil
+	}
+
+	p, ok := snap.pickFrom(rnd, snap.index.match(filters))
+	if !ok {
+		return Pick{}, ErrNoMatch
+	}
+	return p, nil
+}
+
+// RandomN returns n random elements matching all filters, honouring
+// configured weights. With unique set, no element is returned twice.
+func (s *Store) RandomN(rnd *rand.Rand, n int, unique bool, filters ...Filter) ([]Pick, error) {
+	snap := s.current.Load()
+	if len(snap.elements) == 0 {
+		return nil, ErrNoElements
+	}
+
+	var candidates []int
+	if len(filters) > 0 {
+		if candidates = snap.index.match(filters); len(candidates) == 0 {
+			return nil, ErrNoMatch
+		}
+	}
+
+	picked := make([]Pick, 0, n)
+	if !unique {
+		for range n {
+			if candidates == nil {
+				picked = append(picked, snap.pick(rnd))
+				continue
+			}
+			p, ok := snap.pickFrom(rnd, candidates)
+			if !ok {
+				return nil, ErrNoMatch
+			}
+			picked = append(picked, p)
+		}
+		return picked, nil
+	}
+
+	idxs, err := snap.sampleUnique(rnd, n, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range idxs {
+		picked = append(picked, snap.at(idx))
+	}
+	return picked, nil
+}
+
+// Replace validates elements, builds their lookup structures and atomically
+// swaps them in. The current elements are kept if validation or building fails.
+func (s *Store) Replace(elements Elements) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elements, skipped, err := s.validate(elements)
+	if err != nil {
+		return err
+	}
+
+	snap, err := s.build(elements)
+	if err != nil {
+		return err
+	}
+	snap.skipped = skipped
+
+	s.current.Store(snap)
+	s.failedReloads.Store(0)
+	return nil
+}
+
+// Append adds elem to the end of the set and returns its index.
+func (s *Store) Append(elem Element) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.current.Load().elements
+	elements := make(Elements, len(current), len(current)+1)
+	copy(elements, current)
+	elements = append(elements, elem)
+
+	if err := s.checkElement(len(current), elem); err != nil {
+		return 0, err
+	}
+	if err := s.commit(elements); err != nil {
+		return 0, err
+	}
+	return len(elements) - 1, nil
+}
+
+// Update replaces the element at idx.
+func (s *Store) Update(idx int, elem Element) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.current.Load().elements
+	if idx < 0 || idx >= len(current) {
+		return ErrIndexOutOfRange
+	}
+	elements := make(Elements, len(current))
+	copy(elements, current)
+	elements[idx] = elem
+
+	if err := s.checkElement(idx, elem); err != nil {
+		return err
+	}
+	return s.commit(elements)
+}
+
+// Delete removes the element at idx; later elements shift down by one.
+func (s *Store) Delete(idx int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.current.Load().elements
+	if idx < 0 || idx >= len(current) {
+		return ErrIndexOutOfRange
+	}
+	if len(current) == 1 {
+		return ErrLastElement
+	}
+	elements := make(Elements, 0, len(current)-1)
+	elements = append(elements, current[:idx]...)
+	elements = append(elements, current[idx+1:]...)
+
+	return s.commit(elements)
+}
+
+// commit persists an edited element set, if configured, and swaps it in.
+// Callers must hold s.mu.
+func (s *Store) commit(elements Elements) error {
+	snap, err := s.build(elements)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidElement, err)
+	}
+
+	if s.opts.PersistPath != "" {
+		if err := WriteElements(s.opts.PersistPath, s.opts.PersistFormat, elements); err != nil {
+			return err
+		}
+	}
+
+	s.current.Store(snap)
+	return nil
+}
+
+// validate checks elements against the schema, if configured. With
+// SkipInvalid, invalid elements are dropped and returned as the violations
+// instead of failing, unless no valid element remains.
+func (s *Store) validate(elements Elements) (Elements, *SchemaError, error) {
+	if s.opts.Schema == nil {
+		return elements, nil, nil
+	}
+
+	err := s.opts.Schema.Validate(elements)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		return elements, nil, err
+	}
+	if !s.opts.SkipInvalid {
+		return nil, nil, err
+	}
+
+	invalid := schemaErr.Indexes()
+	if len(invalid) == len(elements) {
+		return nil, nil, fmt.Errorf("no valid elements: %w", err)
+	}
+	valid := make(Elements, 0, len(elements)-len(invalid))
+	for i, elem := range elements {
+		if _, found := slices.BinarySearch(invalid, i); !found {
+			valid = append(valid, elem)
+		}
+	}
+	return valid, schemaErr, nil
+}
+
+// checkElement validates an edited element at idx against the schema, if
+// configured.
+func (s *Store) checkElement(idx int, elem Element) error {
+	if s.opts.Schema == nil {
+		return nil
+	}
+	if violations := s.opts.Schema.violations(idx, elem); len(violations) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidElement, &SchemaError{Violations: violations})
+	}
+	return nil
+}
+
+// build creates a snapshot with all lookup structures for elements.
+func (s *Store) build(elements Elements) (*snapshot, error) {
+	snap := &snapshot{
+		elements: elements,
+		etags:    buildETags(elements),
+		pickable: len(elements),
+		index:    buildFieldIndex(elements, s.opts.FilterFields),
+		loadedAt: time.Now(),
+		gen:      s.generations.Add(1),
+	}
+
+	if s.opts.CompressMinSize > 0 {
+		variants, err := s.precompress(elements, snap.etags)
+		if err != nil {
+			return nil, err
+		}
+		snap.variants = variants
+	}
+
+	if len(elements) > 0 {
+		weights, err := s.opts.weights(elements)
+		if err != nil {
+			return nil, err
+		}
+		if weights != nil {
+			if snap.sampler, err = newAliasSampler(weights); err != nil {
+				return nil, err
+			}
+			snap.weights = weights
+			snap.pickable = 0
+			for _, w := range weights {
+				if w > 0 {
+					snap.pickable++
+				}
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// precompress returns the pre-compressed variants of all elements large
+// enough to be compressed. Variants of unchanged elements are taken from the
+// current snapshot so edits and reloads only compress what changed.
+func (s *Store) precompress(elements Elements, etags []string) ([]compress.Variants, error) {
+	previous := make(map[string]compress.Variants)
+	if current := s.current.Load(); current != nil && current.variants != nil {
+		for i, etag := range current.etags {
+			if current.variants[i] != nil {
+				previous[etag] = current.variants[i]
+			}
+		}
+	}
+
+	variants := make([]compress.Variants, len(elements))
+	for i, elem := range elements {
+		if len(elem) < s.opts.CompressMinSize {
+			continue
+		}
+		if v, ok := previous[etags[i]]; ok {
+			variants[i] = v
+			continue
+		}
+		v, err := compress.Precompress(elem)
+		if err != nil {
+			return nil, err
+		}
+		variants[i] = v
+	}
+	return variants, nil
+}
@@ -0,0 +1,376 @@
+package data_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/compress"
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("serves initial elements", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`1`), []byte(`2`)})
+
+		assert.Len(t, store.Elements(), 2)
+		assert.False(t, store.LoadedAt().IsZero())
+	})
+
+	t.Run("replace swaps elements", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`1`)})
+		before := store.LoadedAt()
+
+		require.NoError(t, store.Replace(data.Elements{[]byte(`"a"`), []byte(`"b"`), []byte(`"c"`)}))
+
+		assert.Len(t, store.Elements(), 3)
+		assert.Equal(t, `"a"`, string(store.Elements()[0]))
+		assert.False(t, store.LoadedAt().Before(before))
+	})
+
+	t.Run("index returns element with stable etag", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)})
+
+		pick, err := store.Index(1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, pick.Index)
+		assert.Equal(t, `"b"`, string(pick.Element))
+		assert.Regexp(t, `^"[0-9a-f]{32}"$`, pick.ETag)
+
+		first, err := store.Index(0)
+		require.NoError(t, err)
+		assert.NotEqual(t, pick.ETag, first.ETag)
+
+		// Reloading identical content keeps the entity tag.
+		require.NoError(t, store.Replace(data.Elements{[]byte(`"b"`)}))
+		reloaded, err := store.Index(0)
+		require.NoError(t, err)
+		assert.Equal(t, pick.ETag, reloaded.ETag)
+
+		_, err = store.Index(1)
+		assert.ErrorIs(t, err, data.ErrIndexOutOfRange)
+		_, err = store.Index(-1)
+		assert.ErrorIs(t, err, data.ErrIndexOutOfRange)
+	})
+
+	t.Run("pre-compresses large elements", func(t *testing.T) {
+		t.Parallel()
+
+		large := []byte(`"` + strings.Repeat("ab", 100) + `"`)
+		store, err := data.NewStore(data.Elements{[]byte(`"small"`), large}, data.Options{CompressMinSize: 64})
+		require.NoError(t, err)
+
+		small, err := store.Index(0)
+		require.NoError(t, err)
+		assert.Nil(t, small.Variants)
+
+		pick, err := store.Index(1)
+		require.NoError(t, err)
+		assert.Contains(t, pick.Variants, compress.Gzip)
+		assert.Contains(t, pick.Variants, compress.Brotli)
+		assert.Contains(t, pick.Variants, compress.Zstd)
+
+		// Unchanged elements keep their variants across edits.
+		_, err = store.Append([]byte(`"new"`))
+		require.NoError(t, err)
+		edited, err := store.Index(1)
+		require.NoError(t, err)
+		assert.Same(t, &pick.Variants[compress.Gzip][0], &edited.Variants[compress.Gzip][0])
+	})
+
+	t.Run("random reports empty store", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{})
+
+		_, err := store.Random(rand.New(rand.NewSource(1)))
+		assert.ErrorIs(t, err, data.ErrNoElements)
+	})
+
+	t.Run("weight pointer skews random picks", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{
+			[]byte(`{"msg":"never","weight":0}`),
+			[]byte(`{"msg":"rare"}`),
+			[]byte(`{"msg":"often","weight":9}`),
+			[]byte(`"plain string"`),
+		}
+		store, err := data.NewStore(elements, data.Options{WeightPointer: "/weight"})
+		require.NoError(t, err)
+
+		counts := map[string]int{}
+		rnd := rand.New(rand.NewSource(42))
+		for range 11000 {
+			elem, err := store.Random(rnd)
+			require.NoError(t, err)
+			counts[string(elem.Element)]++
+		}
+
+		// Expected shares: 0, 1/11, 9/11, 1/11.
+		assert.Zero(t, counts[`{"msg":"never","weight":0}`])
+		assert.InDelta(t, 1000, counts[`{"msg":"rare"}`], 150)
+		assert.InDelta(t, 9000, counts[`{"msg":"often","weight":9}`], 300)
+		assert.InDelta(t, 1000, counts[`"plain string"`], 150)
+	})
+
+	t.Run("weights path skews random picks", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "weights.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[0, 1]`), 0o600))
+
+		store, err := data.NewStore(data.Elements{[]byte(`"a"`), []byte(`"b"`)}, data.Options{WeightsPath: path})
+		require.NoError(t, err)
+
+		rnd := rand.New(rand.NewSource(1))
+		for range 100 {
+			elem, _ := store.Random(rnd)
+			assert.Equal(t, `"b"`, string(elem.Element))
+		}
+	})
+
+	t.Run("rejects invalid weights", func(t *testing.T) {
+		t.Parallel()
+
+		tests := map[string]data.Elements{
+			"not a number": {[]byte(`{"weight":"heavy"}`)},
+			"negative":     {[]byte(`{"weight":-1}`)},
+			"all zero":     {[]byte(`{"weight":0}`), []byte(`{"weight":0}`)},
+		}
+		for name, elements := range tests {
+			_, err := data.NewStore(elements, data.Options{WeightPointer: "/weight"})
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("rejects weights path with wrong length", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "weights.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[1, 2, 3]`), 0o600))
+
+		_, err := data.NewStore(data.Elements{[]byte(`1`)}, data.Options{WeightsPath: path})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "got 3 weights for 1 elements")
+	})
+
+	t.Run("random n picks with replacement", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`1`), []byte(`2`)})
+
+		picked, err := store.RandomN(rand.New(rand.NewSource(1)), 10, false)
+		require.NoError(t, err)
+		assert.Len(t, picked, 10)
+	})
+
+	t.Run("random n unique picks distinct elements", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`1`), []byte(`2`), []byte(`3`)})
+
+		picked, err := store.RandomN(rand.New(rand.NewSource(1)), 3, true)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"1", "2", "3"}, []string{string(picked[0].Element), string(picked[1].Element), string(picked[2].Element)})
+
+		_, err = store.RandomN(rand.New(rand.NewSource(1)), 4, true)
+		assert.ErrorIs(t, err, data.ErrNotEnoughElements)
+	})
+
+	t.Run("random n unique skips zero weights", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`{"w":0}`), []byte(`{"w":1}`), []byte(`{"w":5}`)}
+		store, err := data.NewStore(elements, data.Options{WeightPointer: "/w"})
+		require.NoError(t, err)
+
+		picked, err := store.RandomN(rand.New(rand.NewSource(1)), 2, true)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{`{"w":1}`, `{"w":5}`}, []string{string(picked[0].Element), string(picked[1].Element)})
+
+		_, err = store.RandomN(rand.New(rand.NewSource(1)), 3, true)
+		assert.ErrorIs(t, err, data.ErrNotEnoughElements)
+	})
+
+	t.Run("random n reports empty store", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{})
+
+		_, err := store.RandomN(rand.New(rand.NewSource(1)), 1, false)
+		assert.ErrorIs(t, err, data.ErrNoElements)
+	})
+
+	t.Run("random honours filters", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{
+			[]byte(`{"type":"general","lang":"en"}`),
+			[]byte(`{"type":"programming","lang":"en"}`),
+			[]byte(`{"type":"programming","lang":"de"}`),
+			[]byte(`"plain string"`),
+		}
+		store := newStore(t, elements)
+		rnd := rand.New(rand.NewSource(1))
+
+		for range 50 {
+			elem, err := store.Random(rnd, data.Filter{Field: "type", Value: "programming"}, data.Filter{Field: "lang", Value: "de"})
+			require.NoError(t, err)
+			assert.Equal(t, `{"type":"programming","lang":"de"}`, string(elem.Element))
+			assert.Equal(t, 2, elem.Index)
+		}
+
+		_, err := store.Random(rnd, data.Filter{Field: "type", Value: "knock-knock"})
+		assert.ErrorIs(t, err, data.ErrNoMatch)
+	})
+
+	t.Run("random n honours filters and weights", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{
+			[]byte(`{"type":"a","w":0}`),
+			[]byte(`{"type":"a","w":1}`),
+			[]byte(`{"type":"a","w":2}`),
+			[]byte(`{"type":"b","w":5}`),
+		}
+		store, err := data.NewStore(elements, data.Options{WeightPointer: "/w"})
+		require.NoError(t, err)
+		rnd := rand.New(rand.NewSource(1))
+		filter := data.Filter{Field: "type", Value: "a"}
+
+		picked, err := store.RandomN(rnd, 20, false, filter)
+		require.NoError(t, err)
+		for _, elem := range picked {
+			assert.NotEqual(t, `{"type":"a","w":0}`, string(elem.Element))
+			assert.NotEqual(t, `{"type":"b","w":5}`, string(elem.Element))
+		}
+
+		picked, err = store.RandomN(rnd, 2, true, filter)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{`{"type":"a","w":1}`, `{"type":"a","w":2}`}, []string{string(picked[0].Element), string(picked[1].Element)})
+
+		_, err = store.RandomN(rnd, 3, true, filter)
+		assert.ErrorIs(t, err, data.ErrNotEnoughElements)
+	})
+
+	t.Run("filter fields restrict the index", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`{"type":"a","setup":"long text"}`)}
+		store, err := data.NewStore(elements, data.Options{FilterFields: []string{"type"}})
+		require.NoError(t, err)
+		rnd := rand.New(rand.NewSource(1))
+
+		_, err = store.Random(rnd, data.Filter{Field: "type", Value: "a"})
+		assert.NoError(t, err)
+
+		_, err = store.Random(rnd, data.Filter{Field: "setup", Value: "long text"})
+		assert.ErrorIs(t, err, data.ErrNoMatch)
+	})
+
+	t.Run("failed replace keeps current elements", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := data.NewStore(data.Elements{[]byte(`{"weight":1}`)}, data.Options{WeightPointer: "/weight"})
+		require.NoError(t, err)
+
+		require.Error(t, store.Replace(data.Elements{[]byte(`{"weight":-5}`)}))
+		assert.Equal(t, `{"weight":1}`, string(store.Elements()[0]))
+	})
+
+	t.Run("tracks consecutive reload failures", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`1`)})
+		store.MarkReloadFailed()
+		store.MarkReloadFailed()
+		assert.Equal(t, uint64(2), store.ConsecutiveReloadFailures())
+
+		store.MarkReloaded()
+		assert.Equal(t, uint64(0), store.ConsecutiveReloadFailures())
+
+		store.MarkReloadFailed()
+		require.NoError(t, store.Replace(data.Elements{[]byte(`2`)}))
+		assert.Equal(t, uint64(0), store.ConsecutiveReloadFailures())
+		assert.Equal(t, uint64(3), store.ReloadFailures())
+	})
+
+	t.Run("append update and delete edit elements", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)})
+		before := store.Elements()
+
+		idx, err := store.Append([]byte(`"c"`))
+		require.NoError(t, err)
+		assert.Equal(t, 2, idx)
+
+		require.NoError(t, store.Update(0, []byte(`"z"`)))
+		require.NoError(t, store.Delete(1))
+
+		assert.Equal(t, data.Elements{[]byte(`"z"`), []byte(`"c"`)}, store.Elements())
+		assert.Equal(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)}, before, "previous snapshot must stay untouched")
+	})
+
+	t.Run("edits reject missing indexes and emptying the store", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		assert.ErrorIs(t, store.Update(1, []byte(`"x"`)), data.ErrIndexOutOfRange)
+		assert.ErrorIs(t, store.Delete(-1), data.ErrIndexOutOfRange)
+		assert.ErrorIs(t, store.Delete(0), data.ErrLastElement)
+	})
+
+	t.Run("edit with invalid weight keeps current elements", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := data.NewStore(data.Elements{[]byte(`{"weight":1}`)}, data.Options{WeightPointer: "/weight"})
+		require.NoError(t, err)
+
+		_, err = store.Append([]byte(`{"weight":-1}`))
+		assert.ErrorIs(t, err, data.ErrInvalidElement)
+		assert.Len(t, store.Elements(), 1)
+	})
+
+	t.Run("edits are persisted", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`["a"]`), 0o600))
+
+		store, err := data.NewStore(data.Elements{[]byte(`"a"`)}, data.Options{PersistPath: path, PersistFormat: data.FormatAuto})
+		require.NoError(t, err)
+
+		_, err = store.Append([]byte(`{"b":1}`))
+		require.NoError(t, err)
+
+		elements, err := data.LoadElements(path, data.FormatAuto)
+		require.NoError(t, err)
+		require.Len(t, elements, 2)
+		assert.JSONEq(t, `{"b":1}`, string(elements[1]))
+	})
+}
+
+// newStore returns a store serving elements with default options.
+func newStore(t *testing.T, elements data.Elements) *data.Store {
+	t.Helper()
+
+	store, err := data.NewStore(elements, data.Options{})
+	require.NoError(t, err)
+
+	return store
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/watch"
+)
+
+// Watcher reloads a data file into a Store whenever its content, or that of
+// the store's weights sidecar, changes.
+type Watcher struct {
+	path     string
+	paths    []string // data file and weights sidecar, if any
+	format   Format
+	store    *Store
+	interval time.Duration
+	logger   *slog.Logger
+	checksum [sha256.Size]byte
+}
+
+// NewWatcher returns a Watcher for path that swaps reloaded elements into store.
+// interval is the polling period used when filesystem notifications are unavailable.
+// It should be created right after the initial load so the file's current
+// content is treated as already served.
+func NewWatcher(path string, format Format, store *Store, interval time.Duration, logger *slog.Logger) *Watcher {
+	w := &Watcher{
+		path:     path,
+		paths:    []string{path},
+		format:   format,
+		store:    store,
+		interval: interval,
+		logger:   logger,
+	}
+	if store.opts.WeightsPath != "" {
+		w.paths = append(w.paths, store.opts.WeightsPath)
+	}
+	if checksum, err := filesChecksum(w.paths); err == nil {
+		w.checksum = checksum
+	}
+	return w
+}
+
+// Run watches the data file until ctx is canceled.
+func (w *Watcher) Run(ctx context.Context) {
+	watch.Files(ctx, w.paths, w.interval, w.logger, w.reload)
+}
+
+// reload re-parses the data file if it or the weights changed and swaps it
+// into the store. The previous elements are kept if the new content is invalid.
+func (w *Watcher) reload() {
+	checksum, err := filesChecksum(w.paths)
+	if err != nil {
+		w.store.MarkReloadFailed()
+		w.logger.Warn("read data, keeping previous elements", "path", w.path, "error", err)
+		return
+	}
+	if checksum == w.checksum {
+		// The served content is still current.
+		w.store.MarkReloaded()
+		return
+	}
+
+	elements, err := LoadElements(w.path, w.format)
+	if err == nil && len(elements) == 0 {
+		err = errors.New("no elements available")
+	}
+	if err != nil {
+		w.store.MarkReloadFailed()
+		w.logger.Error("reload data, keeping previous elements", "path", w.path, "error", err)
+		return
+	}
+
+	if err := w.store.Replace(elements); err != nil {
+		w.store.MarkReloadFailed()
+		w.logger.Error("reload data, keeping previous elements", "path", w.path, "error", err)
+		return
+	}
+	// Remember only content that was served, so invalid content keeps
+	// failing until it changes.
+	w.checksum = checksum
+	if skipped := w.store.Skipped(); skipped != nil {
+		w.logger.Warn("skipped invalid elements", "path", w.path, "indexes", skipped.Indexes(), "error", skipped)
+	}
+	w.logger.Info("reloaded data", "path", w.path, "count", len(w.store.Elements()))
+}
+
+// filesChecksum hashes the contents of paths together.
+func filesChecksum(paths []string) ([sha256.Size]byte, error) {
+	var checksum [sha256.Size]byte
+
+	h := sha256.New()
+	for _, path := range paths {
+		sum, err := fileChecksum(path)
+		if err != nil {
+			return checksum, err
+		}
+		h.Write(sum[:])
+	}
+	copy(checksum[:], h.Sum(nil))
+
+	return checksum, nil
+}
+
+// fileChecksum hashes the content of path without holding it in memory.
+func fileChecksum(path string) ([sha256.Size]byte, error) {
+	var checksum [sha256.Size]byte
+
+	f, err := os.Open(path)
+	if err != nil {
+		return checksum, err
+	}
+	defer f.Close() // nolint:errcheck
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return checksum, err
+	}
+	copy(checksum[:], h.Sum(nil))
+
+	return checksum, nil
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	// startWatcher runs w until the test ends.
+	startWatcher := func(t *testing.T, run func(context.Context)) {
+		t.Helper()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		var wg sync.WaitGroup
+		wg.Go(func() { run(ctx) })
+		t.Cleanup(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+
+	t.Run("reloads on file change", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[1]`), 0o600))
+
+		store := newTestStore(t, Elements{[]byte(`1`)})
+		w := NewWatcher(path, FormatAuto, store, time.Hour, logger)
+		startWatcher(t, w.Run)
+
+		require.NoError(t, os.WriteFile(path, []byte(`[1, 2, 3]`), 0o600))
+
+		assert.Eventually(t, func() bool {
+			return len(store.Elements()) == 3
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reloads on configmap symlink swap", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		gen1 := filepath.Join(dir, "..2026_01_01")
+		gen2 := filepath.Join(dir, "..2026_01_02")
+		require.NoError(t, os.Mkdir(gen1, 0o700))
+		require.NoError(t, os.Mkdir(gen2, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(gen1, "data.json"), []byte(`[1]`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(gen2, "data.json"), []byte(`[1, 2]`), 0o600))
+		require.NoError(t, os.Symlink(filepath.Base(gen1), filepath.Join(dir, "..data")))
+		require.NoError(t, os.Symlink(filepath.Join("..data", "data.json"), filepath.Join(dir, "data.json")))
+
+		store := newTestStore(t, Elements{[]byte(`1`)})
+		w := NewWatcher(filepath.Join(dir, "data.json"), FormatAuto, store, time.Hour, logger)
+		startWatcher(t, w.Run)
+
+		// Atomically repoint "..data" like the kubelet does.
+		tmpLink := filepath.Join(dir, "..data_tmp")
+		require.NoError(t, os.Symlink(filepath.Base(gen2), tmpLink))
+		require.NoError(t, os.Rename(tmpLink, filepath.Join(dir, "..data")))
+
+		assert.Eventually(t, func() bool {
+			return len(store.Elements()) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reloads on weights change", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "data.json")
+		weightsPath := filepath.Join(dir, "weights.json")
+		require.NoError(t, os.WriteFile(path, []byte(`["a", "b"]`), 0o600))
+		require.NoError(t, os.WriteFile(weightsPath, []byte(`[1, 0]`), 0o600))
+
+		elements, err := LoadElements(path, FormatAuto)
+		require.NoError(t, err)
+		store, err := NewStore(elements, Options{WeightsPath: weightsPath})
+		require.NoError(t, err)
+		w := NewWatcher(path, FormatAuto, store, time.Hour, logger)
+		startWatcher(t, w.Run)
+
+		require.NoError(t, os.WriteFile(weightsPath, []byte(`[0, 1]`), 0o600))
+
+		rnd := rand.New(rand.NewSource(1))
+		assert.Eventually(t, func() bool {
+			pick, err := store.Random(rnd)
+			return err == nil && pick.Index == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keeps previous elements on invalid content", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0o600))
+
+		store := newTestStore(t, Elements{[]byte(`1`), []byte(`2`)})
+		w := NewWatcher(path, FormatAuto, store, time.Hour, logger)
+
+		require.NoError(t, os.WriteFile(path, []byte(`{ not json`), 0o600))
+		w.reload()
+		assert.Len(t, store.Elements(), 2)
+
+		require.NoError(t, os.WriteFile(path, []byte(`[]`), 0o600))
+		w.reload()
+		assert.Len(t, store.Elements(), 2)
+
+		require.NoError(t, os.Remove(path))
+		w.reload()
+		assert.Len(t, store.Elements(), 2)
+		assert.Equal(t, uint64(3), store.ReloadFailures())
+	})
+
+	t.Run("resets consecutive failures when content is unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0o600))
+
+		store := newTestStore(t, Elements{[]byte(`1`), []byte(`2`)})
+		w := NewWatcher(path, FormatAuto, store, time.Hour, logger)
+
+		require.NoError(t, os.WriteFile(path, []byte(`{ not json`), 0o600))
+		w.reload()
+		w.reload()
+		assert.Equal(t, uint64(2), store.ConsecutiveReloadFailures())
+
+		require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0o600))
+		w.reload()
+		assert.Equal(t, uint64(0), store.ConsecutiveReloadFailures())
+		assert.Equal(t, uint64(2), store.ReloadFailures())
+	})
+}
+
+// newTestStore returns a store serving elements with default options.
+func newTestStore(t *testing.T, elements Elements) *Store {
+	t.Helper()
+
+	store, err := NewStore(elements, Options{})
+	require.NoError(t, err)
+
+	return store
+}
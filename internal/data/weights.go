@@ -0,0 +1,78 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// defaultWeight applies to elements that carry no weight of their own.
+const defaultWeight = 1.0
+
+// weights returns one weight per element, or nil when selection is uniform.
+func (o Options) weights(elements Elements) ([]float64, error) {
+	switch {
+	case o.WeightsPath != "":
+		return loadWeights(o.WeightsPath, len(elements))
+	case o.WeightPointer != "":
+		return pointerWeights(elements, o.WeightPointer)
+	default:
+		return nil, nil
+	}
+}
+
+// loadWeights reads a sidecar JSON array with one weight per element.
+func loadWeights(path string, count int) ([]float64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read weights: %w", err)
+	}
+
+	var weights []float64
+	if err := json.Unmarshal(content, &weights); err != nil {
+		return nil, fmt.Errorf("unmarshal weights: %w", err)
+	}
+	if len(weights) != count {
+		return nil, fmt.Errorf("weights: got %d weights for %d elements", len(weights), count)
+	}
+	for i, w := range weights {
+		if err := checkWeight(w); err != nil {
+			return nil, fmt.Errorf("weights: index %d: %w", i, err)
+		}
+	}
+
+	return weights, nil
+}
+
+// pointerWeights reads each element's weight from the value at pointer.
+// Elements without that value get the default weight.
+func pointerWeights(elements Elements, pointer string) ([]float64, error) {
+	weights := make([]float64, len(elements))
+	for i, elem := range elements {
+		raw, ok := lookupPointer(elem, pointer)
+		if !ok {
+			weights[i] = defaultWeight
+			continue
+		}
+
+		var w float64
+		if err := json.Unmarshal(raw, &w); err != nil {
+			return nil, fmt.Errorf("element %d: weight at %q is not a number", i, pointer)
+		}
+		if err := checkWeight(w); err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		weights[i] = w
+	}
+
+	return weights, nil
+}
+
+// checkWeight rejects weights that cannot be used as probabilities.
+func checkWeight(w float64) error {
+	if w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+		return fmt.Errorf("invalid weight %v: must be a finite number >= 0", w)
+	}
+	return nil
+}
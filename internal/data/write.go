@@ -0,0 +1,80 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CanWrite reports whether elements can be written back to path in format.
+func CanWrite(path string, format Format) bool {
+	format = format.resolve(path)
+	return format == FormatJSON || format == FormatNDJSON
+}
+
+// WriteElements atomically replaces the file at path with elements encoded in
+// format. It writes a temporary file in the same directory and renames it over
+// the original so readers never observe a partially written file.
+func WriteElements(path string, format Format, elements Elements) error {
+	if !CanWrite(path, format) {
+		return fmt.Errorf("write data: format %q is not supported", format)
+	}
+
+	var buf bytes.Buffer
+	switch format.resolve(path) {
+	case FormatNDJSON:
+		for _, elem := range elements {
+			if err := json.Compact(&buf, elem); err != nil {
+				return fmt.Errorf("write data: %w", err)
+			}
+			buf.WriteByte('\n')
+		}
+	default:
+		var raw bytes.Buffer
+		raw.WriteByte('[')
+		for i, elem := range elements {
+			if i > 0 {
+				raw.WriteByte(',')
+			}
+			raw.Write(elem)
+		}
+		raw.WriteByte(']')
+		if err := json.Indent(&buf, raw.Bytes(), "", "  "); err != nil {
+			return fmt.Errorf("write data: %w", err)
+		}
+		buf.WriteByte('\n')
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck // no-op after a successful rename
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write data: %w", err)
+	}
+
+	return nil
+}
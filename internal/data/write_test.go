@@ -0,0 +1,60 @@
+package data_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteElements(t *testing.T) {
+	t.Parallel()
+
+	elements := data.Elements{[]byte(`{"a":1}`), []byte(`"b"`)}
+
+	t.Run("writes indented JSON array", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[]`), 0o600))
+
+		require.NoError(t, data.WriteElements(path, data.FormatAuto, elements))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "[\n  {\n    \"a\": 1\n  },\n  \"b\"\n]\n", string(content))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary file must be renamed")
+	})
+
+	t.Run("writes NDJSON", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.ndjson")
+
+		require.NoError(t, data.WriteElements(path, data.FormatAuto, elements))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{\"a\":1}\n\"b\"\n", string(content))
+	})
+
+	t.Run("rejects unsupported formats", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.yaml")
+
+		assert.False(t, data.CanWrite(path, data.FormatAuto))
+		assert.True(t, data.CanWrite(path, data.FormatJSON))
+		assert.Error(t, data.WriteElements(path, data.FormatAuto, elements))
+	})
+}
@@ -1,10 +1,18 @@
 package flag
 
 import (
+	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/logging"
+	"github.com/gi8lino/randomapi/internal/middleware"
 
 	"github.com/containeroo/httpprefix"
 	"github.com/containeroo/tinyflags"
@@ -16,10 +24,81 @@ type Config struct {
 	LogFormat        logging.LogFormat // Log output format (text or json)
 	Debug            bool              // Enable debug mode
 	RoutePrefix      string            // Canonical path prefix ("" or "/random-api")
-	DataPath         string            // Path to JSON file with elements
+	DataPath         string            // Path to JSON file with elements ("" when only named datasets are served)
+	DataFormat       data.Format       // Format of the data files (auto detects by extension)
+	Datasets         map[string]string // Named datasets (name → path)
+	DatasetDir       string            // Directory of JSON files served as named datasets
+	WeightPointer    string            // JSON pointer to a numeric weight inside object elements
+	WeightsPath      string            // Sidecar JSON array with one weight per element of the default dataset
+	SchemaPath       string            // JSON Schema every element of every dataset must match ("" = no validation)
+	OnInvalid        string            // Handling of elements violating SchemaPath (fail or skip)
+	FilterFields     []string          // Top-level fields indexed for ?filter= (empty = all)
+	Seed             int64             // Seed for the random generator (0 = seeded from the current time)
+	MaxCount         int               // Maximum number of elements returned by /random?count=N
+	Selection        string            // Selection mode for /random (random or shuffle-bag)
+	ShuffleClients   int               // Maximum number of client decks kept for shuffle-bag selection
+	Location         *time.Location    // Time zone for /daily, /hourly and /period
+	TextTemplate     string            // text/template for text/plain responses ("@file" reads a file)
+	HTMLTemplate     string            // html/template for text/html responses ("@file" reads a file)
+	CompressMinSize  int               // Compress responses of at least this many bytes (0 = disabled)
+	IndexMaxAge      time.Duration     // Cache-Control max-age for /index/{nr} (0 = clients must revalidate)
+	OpenAPISchema    bool              // Infer element schemas from the data in /openapi.json
+	RefreshInterval  time.Duration     // Interval for re-fetching HTTP(S) data sources (0 = never)
+	FetchTimeout     time.Duration     // Timeout for fetching HTTP(S) data sources
+	Watch            bool              // Reload the data file when it changes
+	WatchInterval    time.Duration     // Polling interval when filesystem notifications are unavailable
+	AccessLog        bool              // Log one line per request
+	AccessLogSkip    bool              // Do not log health-check requests
+	TLSCertFile      string            // PEM certificate chain served over TLS ("" = plain HTTP)
+	TLSKeyFile       string            // PEM private key of TLSCertFile
+	TLSClientCAFile  string            // PEM CA bundle verifying client certificates ("" = no mutual TLS)
+	TLSClientAuth    string            // Client certificate policy (require or verify-if-given)
+	MetricsAddr      string            // Separate listen address for /metrics ("" = serve on the main listener)
+	RateLimit        float64           // Sustained requests per second per client (0 = unlimited)
+	RateLimitBurst   int               // Requests a client may make at once
+	RateLimitKey     string            // Rate limit clients by ip or api-key
+	RateLimitClients int               // Maximum number of clients tracked for rate limiting
+	TrustedProxies   []netip.Prefix    // Proxies whose X-Forwarded-For header is trusted
+	CORSOrigins      []string          // Origins allowed to read responses cross-origin (empty = CORS disabled)
+	CORSMethods      []string          // Methods allowed in CORS preflight requests
+	CORSHeaders      []string          // Request headers allowed in CORS preflight requests
+	CORSExposed      []string          // Response headers cross-origin scripts may read
+	CORSMaxAge       time.Duration     // How long browsers may cache CORS preflight results
+	CORSCredentials  bool              // Allow credentialed cross-origin requests
+	ShutdownDelay    time.Duration     // Time /readyz reports not ready before the server shuts down
+	MaxReloadFails   int               // Consecutive reload failures before /readyz fails (0 = ignore)
+	APIKeys          []string          // API key entries ("KEY [SCOPE...]") required for requests
+	APIKeysFile      string            // File with one API key entry per line
+	AuthExempt       []string          // Path patterns served without an API key
+	AdminToken       string            // Bearer token for the /admin API ("" = disabled)
+	AdminPersist     bool              // Write admin edits back to the data files
 	OverriddenValues map[string]any    // Overridden values from environment
 }
 
+// Selection modes for /random.
+const (
+	SelectionRandom     = "random"
+	SelectionShuffleBag = "shuffle-bag"
+)
+
+// Handling of elements that violate --schema-path.
+const (
+	OnInvalidFail = "fail"
+	OnInvalidSkip = "skip"
+)
+
+// Client certificate policies for mutual TLS.
+const (
+	TLSClientAuthRequire       = "require"
+	TLSClientAuthVerifyIfGiven = "verify-if-given"
+)
+
+// Keys for per-client rate limiting.
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyAPIKey = "api-key"
+)
+
 // ParseArgs parses CLI args into Config.
 func ParseArgs(version string, args []string, out io.Writer) (Config, error) {
 	var cfg Config
@@ -27,6 +106,7 @@ func ParseArgs(version string, args []string, out io.Writer) (Config, error) {
 	tf.Version(version)
 	tf.EnvPrefix("RANDOMAPI")
 	tf.SetOutput(out)
+	tf.Note("Run 'random-api validate [flags]' to check the data files with the same flags and exit.")
 
 	// Server
 	tf.StringVar(&cfg.RoutePrefix, "route-prefix", "", "Path prefix to mount the app (e.g., /random-api). Empty = root.").
@@ -40,10 +120,242 @@ func ParseArgs(version string, args []string, out io.Writer) (Config, error) {
 		Placeholder("ADDR:PORT").
 		Value()
 
-	dataPath := tf.String("data-path", "/app/data.json", "Path to JSON file with elements.").
+	disableAccessLog := tf.Bool("disable-access-log", false, "Disable logging one line per HTTP request.").
+		Value()
+	tf.BoolVar(&cfg.AccessLogSkip, "access-log-skip-health", false, "Do not log requests to /healthz and /readyz.").
+		Value()
+	tf.StringVar(&cfg.TLSCertFile, "tls-cert-file", "", "PEM certificate chain to serve HTTPS with; reloaded when it changes.").
+		AllOrNone("tls").
+		Placeholder("PATH").
+		Value()
+	tf.StringVar(&cfg.TLSKeyFile, "tls-key-file", "", "PEM private key for --tls-cert-file; reloaded when it changes.").
+		AllOrNone("tls").
+		Placeholder("PATH").
+		Value()
+	tf.StringVar(&cfg.TLSClientCAFile, "tls-client-ca-file", "", "PEM CA bundle to verify client certificates against (enables mutual TLS).").
+		Requires("tls-cert-file").
+		Placeholder("PATH").
+		Value()
+	tf.StringVar(&cfg.TLSClientAuth, "tls-client-auth", TLSClientAuthRequire, "Client certificate policy with --tls-client-ca-file: require, or verify-if-given.").
+		Choices(TLSClientAuthRequire, TLSClientAuthVerifyIfGiven).
+		Value()
+	tf.StringVar(&cfg.MetricsAddr, "metrics-listen-address", "", "Separate listen address for /metrics (empty = serve on --listen-address).").
+		Validate(func(addr string) error {
+			_, err := net.ResolveTCPAddr("tcp", addr)
+			return err
+		}).
+		Placeholder("ADDR:PORT").
+		Value()
+
+	tf.Float64Var(&cfg.RateLimit, "rate-limit", 0, "Sustained requests per second allowed per client (0 disables rate limiting).").
+		Validate(func(f float64) error {
+			if f < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		}).
+		Placeholder("RPS").
+		Value()
+	tf.IntVar(&cfg.RateLimitBurst, "rate-limit-burst", 20, "Requests a client may make at once before --rate-limit applies.").
+		Validate(func(n int) error {
+			if n < 1 {
+				return fmt.Errorf("must be at least 1")
+			}
+			return nil
+		}).
+		Placeholder("N").
+		Value()
+	tf.StringVar(&cfg.RateLimitKey, "rate-limit-key", RateLimitKeyIP, "Identify rate limited clients by ip, or by api-key (a configured X-API-Key or bearer token, falling back to the IP).").
+		Choices(RateLimitKeyIP, RateLimitKeyAPIKey).
+		Value()
+	tf.IntVar(&cfg.RateLimitClients, "rate-limit-max-clients", 10000, "Maximum number of clients tracked in memory for rate limiting.").
+		Validate(func(n int) error {
+			if n < 1 {
+				return fmt.Errorf("must be at least 1")
+			}
+			return nil
+		}).
+		Placeholder("N").
+		Value()
+	trustedProxies := tf.StringSlice("trusted-proxies", nil, "CIDR or IP of proxies whose X-Forwarded-For header is trusted for the client IP (repeatable).").
+		Validate(func(s string) error {
+			_, err := parseProxy(s)
+			return err
+		}).
+		Placeholder("CIDR").
+		Value()
+
+	tf.StringSliceVar(&cfg.CORSOrigins, "cors-allowed-origin", nil, "Origin allowed to fetch responses from a browser; * matches any part, e.g. https://*.example.com (repeatable).").
+		Validate(func(s string) error {
+			if s != "*" && !strings.Contains(s, "://") {
+				return fmt.Errorf("must be * or scheme://host[:port]")
+			}
+			return nil
+		}).
+		Placeholder("ORIGIN").
+		Value()
+	tf.StringSliceVar(&cfg.CORSMethods, "cors-allowed-method", []string{http.MethodGet, http.MethodHead}, "Method allowed in CORS preflight requests (repeatable).").
+		Placeholder("METHOD").
+		Value()
+	tf.StringSliceVar(&cfg.CORSHeaders, "cors-allowed-header", []string{"Authorization", "X-API-Key"}, "Request header allowed in CORS preflight requests; * allows any (repeatable).").
+		Placeholder("HEADER").
+		Value()
+	tf.StringSliceVar(&cfg.CORSExposed, "cors-exposed-header", []string{"ETag", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, "Response header cross-origin scripts may read (repeatable).").
+		Placeholder("HEADER").
+		Value()
+	tf.DurationVar(&cfg.CORSMaxAge, "cors-max-age", 10*time.Minute, "How long browsers may cache CORS preflight results (0 omits Access-Control-Max-Age).").
+		Validate(func(d time.Duration) error {
+			if d < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		}).
+		Placeholder("DURATION").
+		Value()
+	tf.BoolVar(&cfg.CORSCredentials, "cors-allow-credentials", false, "Allow cross-origin requests with cookies or authorization headers.").
+		Value()
+
+	tf.DurationVar(&cfg.ShutdownDelay, "shutdown-delay", 0, "Time /readyz reports not ready before the server shuts down on a signal.").
+		Placeholder("DURATION").
+		Value()
+	tf.IntVar(&cfg.MaxReloadFails, "ready-max-reload-failures", 3, "Consecutive reload failures after which /readyz reports not ready (0 disables).").
+		Validate(func(n int) error {
+			if n < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		}).
+		Placeholder("N").
+		Value()
+
+	dataPathFlag := tf.String("data-path", "/app/data.json", "Path or http(s):// URL of the data file with elements.").
+		Placeholder("PATH")
+	dataPath := dataPathFlag.Value()
+	dataFormat := tf.String("data-format", string(data.FormatAuto), "Format of the data files; auto detects it from the file extension.").
+		Choices(formatNames()...).
+		Value()
+	datasets := tf.StringSlice("dataset", nil, "Named dataset served under /{name} (repeatable).").
+		Validate(func(s string) error {
+			_, _, err := parseDataset(s)
+			return err
+		}).
+		Placeholder("NAME=PATH").
+		Value()
+	tf.StringVar(&cfg.DatasetDir, "dataset-dir", "", "Directory of data files served as datasets named after each file.").
+		Placeholder("DIR").
+		Value()
+	tf.StringVar(&cfg.WeightPointer, "weight-pointer", "", "JSON pointer to a numeric weight inside object elements (e.g. /weight).").
+		Validate(data.ValidatePointer).
+		OneOfGroup("weights").
+		Placeholder("POINTER").
+		Value()
+	tf.StringVar(&cfg.WeightsPath, "weights-path", "", "Path to a JSON array with one weight per element of --data-path.").
+		OneOfGroup("weights").
+		Placeholder("PATH").
+		Value()
+	tf.StringVar(&cfg.SchemaPath, "schema-path", "", "Path to a JSON Schema every element of every dataset must match on load and reload.").
+		Placeholder("PATH").
+		Value()
+	tf.StringVar(&cfg.OnInvalid, "on-invalid", OnInvalidFail, "Elements violating --schema-path fail the load, or are skipped with a warning.").
+		Choices(OnInvalidFail, OnInvalidSkip).
+		Value()
+	tf.StringSliceVar(&cfg.FilterFields, "filter-field", nil, "Top-level field indexed for ?filter= (repeatable, default: all fields).").
+		Placeholder("FIELD").
+		Value()
+	tf.Int64Var(&cfg.Seed, "seed", 0, "Seed for the random generator to make picks deterministic (0 = seeded from the current time).").
+		Placeholder("INT").
+		Value()
+	tf.IntVar(&cfg.MaxCount, "max-count", 100, "Maximum number of elements returned by /random?count=N.").
+		Validate(func(n int) error {
+			if n < 1 {
+				return fmt.Errorf("must be at least 1")
+			}
+			return nil
+		}).
+		Placeholder("N").
+		Value()
+	tf.StringVar(&cfg.Selection, "selection", SelectionRandom, "Selection mode for /random: random picks, or shuffle-bag deals every element once per client before repeating.").
+		Choices(SelectionRandom, SelectionShuffleBag).
+		Value()
+	tf.IntVar(&cfg.ShuffleClients, "shuffle-max-clients", 10000, "Maximum number of client decks kept in memory for --selection=shuffle-bag.").
+		Validate(func(n int) error {
+			if n < 1 {
+				return fmt.Errorf("must be at least 1")
+			}
+			return nil
+		}).
+		Placeholder("N").
+		Value()
+	timezone := tf.String("timezone", "", "Time zone for /daily, /hourly and /period (e.g. Europe/Zurich; empty = TZ or local time).").
+		Validate(func(name string) error {
+			_, err := time.LoadLocation(name)
+			return err
+		}).
+		Placeholder("ZONE").
+		Value()
+	tf.StringVar(&cfg.TextTemplate, "template", "", "Go text/template rendering each element for text/plain responses (@FILE reads a file).").
+		Placeholder("TEMPLATE").
+		Value()
+	tf.StringVar(&cfg.HTMLTemplate, "html-template", "", "Go html/template rendering each element for text/html responses (@FILE reads a file).").
+		Placeholder("TEMPLATE").
+		Value()
+	tf.IntVar(&cfg.CompressMinSize, "compress-min-size", 0, "Compress responses of at least this many bytes with gzip, brotli or zstd (0 disables).").
+		Validate(func(n int) error {
+			if n < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		}).
+		Placeholder("BYTES").
+		Value()
+	tf.DurationVar(&cfg.IndexMaxAge, "index-cache-max-age", time.Minute, "Cache-Control max-age for /index/{nr} responses (0 = clients must revalidate).").
+		Validate(func(d time.Duration) error {
+			if d < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		}).
+		Placeholder("DURATION").
+		Value()
+	tf.BoolVar(&cfg.OpenAPISchema, "openapi-infer-schema", false, "Describe elements in /openapi.json with a JSON Schema inferred from the loaded data.").
+		Value()
+	tf.DurationVar(&cfg.RefreshInterval, "refresh-interval", 5*time.Minute, "Interval for re-fetching http(s):// data sources (0 disables).").
+		Placeholder("DURATION").
+		Value()
+	tf.DurationVar(&cfg.FetchTimeout, "fetch-timeout", 10*time.Second, "Timeout for fetching http(s):// data sources.").
+		Placeholder("DURATION").
+		Value()
+	tf.BoolVar(&cfg.Watch, "watch", false, "Reload the data file when it changes.").
+		Value()
+	tf.DurationVar(&cfg.WatchInterval, "watch-interval", 5*time.Second, "Polling interval used when filesystem notifications are unavailable.").
+		Placeholder("DURATION").
+		Value()
+
+	// Authentication
+	tf.StringSliceVar(&cfg.APIKeys, "api-key", nil, "API key required as bearer token or X-API-Key, optionally followed by the paths it may access (repeatable).").
+		Validate(func(s string) error {
+			_, err := middleware.ParseAPIKey(s)
+			return err
+		}).
+		OverriddenValueMaskFn(func(any) any { return "********" }).
+		Placeholder("KEY [SCOPE...]").
+		Value()
+	tf.StringVar(&cfg.APIKeysFile, "api-keys-file", "", "File with one API key entry (KEY [SCOPE...]) per line.").
+		Placeholder("PATH").
+		Value()
+	tf.StringSliceVar(&cfg.AuthExempt, "auth-exempt", []string{"/healthz", "/readyz"}, "Path served without an API key; a trailing /* matches everything below (repeatable).").
 		Placeholder("PATH").
 		Value()
 
+	// Admin
+	tf.StringVar(&cfg.AdminToken, "admin-token", "", "Bearer token enabling the /admin API to edit elements at runtime.").
+		OverriddenValueMaskFn(func(any) any { return "********" }).
+		Placeholder("TOKEN").
+		Value()
+	tf.BoolVar(&cfg.AdminPersist, "admin-persist", false, "Write admin edits back to the data files (JSON and NDJSON only).").
+		Requires("admin-token").
+		Value()
+
 	// Logging
 	logFormat := tf.String("log-format", "text", "Log format").
 		Choices("text", "json").
@@ -61,8 +373,83 @@ func ParseArgs(version string, args []string, out io.Writer) (Config, error) {
 	// Post-parse
 	cfg.LogFormat = logging.LogFormat(*logFormat)
 	cfg.ListenAddr = (*listenAddr).String()
-	cfg.DataPath = *dataPath
+	cfg.DataFormat = data.Format(*dataFormat)
+	cfg.AccessLog = !*disableAccessLog
+	cfg.Location = time.Local
+	if *timezone != "" {
+		cfg.Location, _ = time.LoadLocation(*timezone) // validated during parsing
+	}
+	for _, s := range *trustedProxies {
+		prefix, _ := parseProxy(s) // validated during parsing
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
 	cfg.OverriddenValues = tf.OverriddenValues()
 
+	cfg.Datasets = make(map[string]string, len(*datasets))
+	for _, s := range *datasets {
+		name, path, _ := parseDataset(s) // validated during parsing
+		if _, exists := cfg.Datasets[name]; exists {
+			return Config{}, fmt.Errorf("duplicate dataset %q", name)
+		}
+		cfg.Datasets[name] = path
+	}
+
+	// Skipping shifts element indexes, which would misalign sidecar weights
+	// and drop the skipped elements from persisted files.
+	if cfg.OnInvalid == OnInvalidSkip && cfg.WeightsPath != "" {
+		return Config{}, fmt.Errorf("--on-invalid=skip cannot be combined with --weights-path")
+	}
+	if cfg.OnInvalid == OnInvalidSkip && cfg.AdminPersist {
+		return Config{}, fmt.Errorf("--on-invalid=skip cannot be combined with --admin-persist")
+	}
+	// Browsers forbid "*" for credentialed requests on purpose; echoing every
+	// origin instead would let any site read responses with the user's cookies.
+	if cfg.CORSCredentials && slices.Contains(cfg.CORSOrigins, "*") {
+		return Config{}, fmt.Errorf("--cors-allow-credentials cannot be combined with --cors-allowed-origin=*")
+	}
+	// Admin edits add and remove elements, which the sidecar weights
+	// cannot follow.
+	if cfg.AdminToken != "" && cfg.WeightsPath != "" {
+		return Config{}, fmt.Errorf("--admin-token cannot be combined with --weights-path")
+	}
+
+	// The default dataset is optional once named datasets are configured.
+	if dataPathFlag.Changed() || (len(cfg.Datasets) == 0 && cfg.DatasetDir == "") {
+		cfg.DataPath = *dataPath
+	}
+
 	return cfg, nil
 }
+
+// formatNames returns the names of the supported data formats.
+func formatNames() []string {
+	names := make([]string, 0, len(data.Formats))
+	for _, f := range data.Formats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+// parseProxy parses a trusted proxy given as CIDR or single IP.
+func parseProxy(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: expected CIDR or IP", s)
+	}
+	return prefix.Masked(), nil
+}
+
+// parseDataset splits a "name=path" dataset definition.
+func parseDataset(s string) (name, path string, err error) {
+	name, path, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return "", "", fmt.Errorf("invalid dataset %q: expected NAME=PATH", s)
+	}
+	if err := data.ValidateDatasetName(name); err != nil {
+		return "", "", err
+	}
+	return name, path, nil
+}
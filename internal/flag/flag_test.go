@@ -1,9 +1,12 @@
 package flag_test
 
 import (
+	"net/netip"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/flag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,6 +27,13 @@ func TestParseArgs(t *testing.T) {
 		assert.Equal(t, "text", string(cfg.LogFormat))
 		assert.Equal(t, ":8080", cfg.ListenAddr)
 		assert.Equal(t, "/app/data.json", cfg.DataPath)
+		assert.Equal(t, data.FormatAuto, cfg.DataFormat)
+		assert.Zero(t, cfg.Seed)
+		assert.Equal(t, 100, cfg.MaxCount)
+		assert.Equal(t, 5*time.Minute, cfg.RefreshInterval)
+		assert.Equal(t, 10*time.Second, cfg.FetchTimeout)
+		assert.False(t, cfg.Watch)
+		assert.Equal(t, 5*time.Second, cfg.WatchInterval)
 	})
 
 	t.Run("route prefix default empty", func(t *testing.T) {
@@ -102,6 +112,471 @@ func TestParseArgs(t *testing.T) {
 		assert.Equal(t, "/path/to/data.json", cfg.DataPath)
 	})
 
+	t.Run("watch flags", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--watch", "--watch-interval=30s"}
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Watch)
+		assert.Equal(t, 30*time.Second, cfg.WatchInterval)
+	})
+
+	t.Run("data format flag", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--data-format=yaml"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, data.FormatYAML, cfg.DataFormat)
+
+		_, err = flag.ParseArgs("dev", []string{"--data-format=xml"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("datasets flag", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--dataset=jokes=/data/jokes.json", "--dataset", "quotes=/data/quotes.json"}
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"jokes":  "/data/jokes.json",
+			"quotes": "/data/quotes.json",
+		}, cfg.Datasets)
+		assert.Empty(t, cfg.DataPath, "default data path is dropped when datasets are configured")
+	})
+
+	t.Run("datasets keep explicit data path", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--dataset=jokes=/data/jokes.json", "--data-path=/data/default.json"}
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, "/data/default.json", cfg.DataPath)
+	})
+
+	t.Run("dataset dir flag", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--dataset-dir=/data"}
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, "/data", cfg.DatasetDir)
+		assert.Empty(t, cfg.DataPath)
+	})
+
+	t.Run("invalid dataset definition", func(t *testing.T) {
+		t.Parallel()
+
+		for _, arg := range []string{"--dataset=jokes", "--dataset=jokes=", "--dataset=a/b=/x.json"} {
+			var out strings.Builder
+			_, err := flag.ParseArgs("dev", []string{arg}, &out)
+			require.Error(t, err, arg)
+		}
+	})
+
+	t.Run("duplicate dataset", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--dataset=jokes=/a.json", "--dataset=jokes=/b.json"}
+		var out strings.Builder
+		_, err := flag.ParseArgs("dev", args, &out)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `duplicate dataset "jokes"`)
+	})
+
+	t.Run("weight flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--weight-pointer=/weight"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "/weight", cfg.WeightPointer)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--weights-path=/data/weights.json"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "/data/weights.json", cfg.WeightsPath)
+	})
+
+	t.Run("invalid weight flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		_, err := flag.ParseArgs("dev", []string{"--weight-pointer=weight"}, &out)
+		require.Error(t, err)
+
+		_, err = flag.ParseArgs("dev", []string{"--weight-pointer=/weight", "--weights-path=/w.json"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("filter field flag", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--filter-field=type", "--filter-field=lang,tags"}, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"type", "lang", "tags"}, cfg.FilterFields)
+	})
+
+	t.Run("remote source flags", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--data-path=https://content.example.com/jokes.json", "--refresh-interval=1m", "--fetch-timeout=3s"}
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://content.example.com/jokes.json", cfg.DataPath)
+		assert.Equal(t, time.Minute, cfg.RefreshInterval)
+		assert.Equal(t, 3*time.Second, cfg.FetchTimeout)
+	})
+
+	t.Run("seed flag", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--seed=1234"}, &out)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1234), cfg.Seed)
+	})
+
+	t.Run("max count flag", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--max-count=25"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 25, cfg.MaxCount)
+
+		_, err = flag.ParseArgs("dev", []string{"--max-count=0"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("admin flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--admin-token=s3cret", "--admin-persist"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.AdminToken)
+		assert.True(t, cfg.AdminPersist)
+
+		_, err = flag.ParseArgs("dev", []string{"--admin-persist"}, &out)
+		require.Error(t, err)
+
+		_, err = flag.ParseArgs("dev", []string{"--admin-token=s3cret", "--weights-path=/app/weights.json"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("metrics listen address", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.MetricsAddr)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--metrics-listen-address=:9090"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, ":9090", cfg.MetricsAddr)
+
+		_, err = flag.ParseArgs("dev", []string{"--metrics-listen-address=nope"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("readiness flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Zero(t, cfg.ShutdownDelay)
+		assert.Equal(t, 3, cfg.MaxReloadFails)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--shutdown-delay=5s", "--ready-max-reload-failures=0"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, cfg.ShutdownDelay)
+		assert.Zero(t, cfg.MaxReloadFails)
+
+		_, err = flag.ParseArgs("dev", []string{"--ready-max-reload-failures=-1"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("access log flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.True(t, cfg.AccessLog)
+		assert.False(t, cfg.AccessLogSkip)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--disable-access-log", "--access-log-skip-health"}, &out)
+		require.NoError(t, err)
+		assert.False(t, cfg.AccessLog)
+		assert.True(t, cfg.AccessLogSkip)
+	})
+
+	t.Run("template flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", []string{"--template={{.setup}}", "--html-template=@/etc/joke.html"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "{{.setup}}", cfg.TextTemplate)
+		assert.Equal(t, "@/etc/joke.html", cfg.HTMLTemplate)
+	})
+
+	t.Run("selection flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Equal(t, flag.SelectionRandom, cfg.Selection)
+		assert.Equal(t, 10000, cfg.ShuffleClients)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--selection=shuffle-bag", "--shuffle-max-clients=50"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, flag.SelectionShuffleBag, cfg.Selection)
+		assert.Equal(t, 50, cfg.ShuffleClients)
+
+		_, err = flag.ParseArgs("dev", []string{"--selection=round-robin"}, &out)
+		require.Error(t, err)
+		_, err = flag.ParseArgs("dev", []string{"--shuffle-max-clients=0"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Equal(t, time.Local, cfg.Location)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--timezone=Europe/Zurich"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Zurich", cfg.Location.String())
+
+		_, err = flag.ParseArgs("dev", []string{"--timezone=Mars/Olympus"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("index cache max age", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, cfg.IndexMaxAge)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--index-cache-max-age=24h"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.IndexMaxAge)
+
+		_, err = flag.ParseArgs("dev", []string{"--index-cache-max-age=-1s"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("schema flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.SchemaPath)
+		assert.Equal(t, flag.OnInvalidFail, cfg.OnInvalid)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--schema-path=/app/schema.json", "--on-invalid=skip"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "/app/schema.json", cfg.SchemaPath)
+		assert.Equal(t, flag.OnInvalidSkip, cfg.OnInvalid)
+
+		for _, args := range [][]string{
+			{"--on-invalid=warn"},
+			{"--on-invalid=skip", "--weights-path=/app/weights.json"},
+			{"--on-invalid=skip", "--admin-token=t", "--admin-persist"},
+		} {
+			_, err = flag.ParseArgs("dev", args, &out)
+			assert.Error(t, err, args)
+		}
+	})
+
+	t.Run("openapi infer schema", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.False(t, cfg.OpenAPISchema)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--openapi-infer-schema"}, &out)
+		require.NoError(t, err)
+		assert.True(t, cfg.OpenAPISchema)
+	})
+
+	t.Run("compress min size", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Zero(t, cfg.CompressMinSize)
+
+		cfg, err = flag.ParseArgs("dev", []string{"--compress-min-size=1024"}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 1024, cfg.CompressMinSize)
+
+		_, err = flag.ParseArgs("dev", []string{"--compress-min-size=-1"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("rate limit flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Zero(t, cfg.RateLimit)
+		assert.Equal(t, 20, cfg.RateLimitBurst)
+		assert.Equal(t, flag.RateLimitKeyIP, cfg.RateLimitKey)
+		assert.Equal(t, 10000, cfg.RateLimitClients)
+		assert.Empty(t, cfg.TrustedProxies)
+
+		args := []string{
+			"--rate-limit=2.5",
+			"--rate-limit-burst=5",
+			"--rate-limit-key=api-key",
+			"--rate-limit-max-clients=100",
+			"--trusted-proxies=10.1.2.3/8",
+			"--trusted-proxies=192.0.2.1",
+		}
+		cfg, err = flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 2.5, cfg.RateLimit)
+		assert.Equal(t, 5, cfg.RateLimitBurst)
+		assert.Equal(t, flag.RateLimitKeyAPIKey, cfg.RateLimitKey)
+		assert.Equal(t, 100, cfg.RateLimitClients)
+		assert.Equal(t, []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("192.0.2.1/32"),
+		}, cfg.TrustedProxies)
+
+		for _, arg := range []string{
+			"--rate-limit=-1",
+			"--rate-limit-burst=0",
+			"--rate-limit-key=token",
+			"--trusted-proxies=not-a-cidr",
+		} {
+			_, err = flag.ParseArgs("dev", []string{arg}, &out)
+			assert.Error(t, err, arg)
+		}
+	})
+
+	t.Run("API key flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.APIKeys)
+		assert.Equal(t, []string{"/healthz", "/readyz"}, cfg.AuthExempt)
+
+		args := []string{"--api-key=k1 /jokes/*", "--api-key=k2", "--api-keys-file=/etc/keys", "--auth-exempt=/healthz"}
+		cfg, err = flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"k1 /jokes/*", "k2"}, cfg.APIKeys)
+		assert.Equal(t, "/etc/keys", cfg.APIKeysFile)
+		assert.Equal(t, []string{"/healthz"}, cfg.AuthExempt)
+
+		_, err = flag.ParseArgs("dev", []string{"--api-key=k1 jokes"}, &out)
+		require.Error(t, err)
+	})
+
+	t.Run("TLS flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.TLSCertFile)
+		assert.Equal(t, flag.TLSClientAuthRequire, cfg.TLSClientAuth)
+
+		args := []string{
+			"--tls-cert-file=/tls/tls.crt",
+			"--tls-key-file=/tls/tls.key",
+			"--tls-client-ca-file=/tls/ca.crt",
+			"--tls-client-auth=verify-if-given",
+		}
+		cfg, err = flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "/tls/tls.crt", cfg.TLSCertFile)
+		assert.Equal(t, "/tls/tls.key", cfg.TLSKeyFile)
+		assert.Equal(t, "/tls/ca.crt", cfg.TLSClientCAFile)
+		assert.Equal(t, flag.TLSClientAuthVerifyIfGiven, cfg.TLSClientAuth)
+
+		for _, args := range [][]string{
+			{"--tls-cert-file=/tls/tls.crt"},
+			{"--tls-client-ca-file=/tls/ca.crt"},
+			{"--tls-client-auth=never"},
+		} {
+			_, err = flag.ParseArgs("dev", args, &out)
+			assert.Error(t, err, args)
+		}
+	})
+
+	t.Run("CORS flags", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+		cfg, err := flag.ParseArgs("dev", nil, &out)
+		require.NoError(t, err)
+		assert.Empty(t, cfg.CORSOrigins)
+		assert.Equal(t, []string{"GET", "HEAD"}, cfg.CORSMethods)
+		assert.Equal(t, []string{"Authorization", "X-API-Key"}, cfg.CORSHeaders)
+		assert.Equal(t, []string{"ETag", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, cfg.CORSExposed)
+		assert.Equal(t, 10*time.Minute, cfg.CORSMaxAge)
+		assert.False(t, cfg.CORSCredentials)
+
+		args := []string{
+			"--cors-allowed-origin=https://example.com",
+			"--cors-allowed-origin=https://*.example.org",
+			"--cors-allowed-method=GET",
+			"--cors-allowed-header=*",
+			"--cors-exposed-header=ETag",
+			"--cors-max-age=1h",
+			"--cors-allow-credentials",
+		}
+		cfg, err = flag.ParseArgs("dev", args, &out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com", "https://*.example.org"}, cfg.CORSOrigins)
+		assert.Equal(t, []string{"GET"}, cfg.CORSMethods)
+		assert.Equal(t, []string{"*"}, cfg.CORSHeaders)
+		assert.Equal(t, []string{"ETag"}, cfg.CORSExposed)
+		assert.Equal(t, time.Hour, cfg.CORSMaxAge)
+		assert.True(t, cfg.CORSCredentials)
+
+		for _, arg := range []string{"--cors-allowed-origin=example.com", "--cors-max-age=-1s"} {
+			_, err = flag.ParseArgs("dev", []string{arg}, &out)
+			assert.Error(t, err, arg)
+		}
+
+		_, err = flag.ParseArgs("dev", []string{"--cors-allowed-origin=*", "--cors-allow-credentials"}, &out)
+		assert.Error(t, err)
+	})
+
 	t.Run("invalid listen address", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// maxElementSize limits the request body accepted by the admin handlers.
+const maxElementSize = 1 << 20
+
+// AddElement returns a handler that appends the JSON element in the request
+// body to store and responds with its index.
+func AddElement(store *data.Store, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		elem, ok := readElement(w, r, logger)
+		if !ok {
+			return
+		}
+
+		idx, err := store.Append(elem)
+		if err != nil {
+			writeEditError(w, err, logger)
+			return
+		}
+
+		logger.Info("added element", "index", idx)
+		writeEditResult(w, http.StatusCreated, idx, len(store.Elements()), logger)
+	}
+}
+
+// UpdateElement returns a handler that replaces the element at the "nr" path
+// value with the JSON element in the request body.
+func UpdateElement(store *data.Store, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idx, ok := editIndex(w, r, logger)
+		if !ok {
+			return
+		}
+		elem, ok := readElement(w, r, logger)
+		if !ok {
+			return
+		}
+
+		if err := store.Update(idx, elem); err != nil {
+			writeEditError(w, err, logger)
+			return
+		}
+
+		logger.Info("updated element", "index", idx)
+		writeEditResult(w, http.StatusOK, idx, len(store.Elements()), logger)
+	}
+}
+
+// DeleteElement returns a handler that removes the element at the "nr" path
+// value. Later elements shift down by one index.
+func DeleteElement(store *data.Store, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idx, ok := editIndex(w, r, logger)
+		if !ok {
+			return
+		}
+
+		if err := store.Delete(idx); err != nil {
+			writeEditError(w, err, logger)
+			return
+		}
+
+		logger.Info("deleted element", "index", idx)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// editIndex parses the "nr" path value.
+func editIndex(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (int, bool) {
+	rawIndex := r.PathValue("nr")
+	idx, err := strconv.Atoi(rawIndex)
+	if err != nil || idx < 0 {
+		logger.Warn("invalid index", "index", rawIndex, "error", err)
+		http.Error(w, "invalid index", http.StatusBadRequest)
+		return 0, false
+	}
+	return idx, true
+}
+
+// readElement reads a single JSON value from the request body and compacts it.
+func readElement(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (data.Element, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxElementSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			logger.Warn("element too large", "limit", maxErr.Limit)
+			http.Error(w, fmt.Sprintf("element exceeds %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		logger.Warn("read request body", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil || buf.Len() == 0 {
+		logger.Warn("invalid element", "error", err)
+		http.Error(w, "invalid JSON element", http.StatusBadRequest)
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// writeEditError maps store edit errors to HTTP responses.
+func writeEditError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, data.ErrIndexOutOfRange):
+		logger.Warn("index out of range", "error", err)
+		http.Error(w, "index out of range", http.StatusNotFound)
+	case errors.Is(err, data.ErrLastElement):
+		logger.Warn("refusing to delete last element")
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, data.ErrInvalidElement):
+		logger.Warn("invalid element", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logger.Error("edit elements", "error", err)
+		http.Error(w, "edit elements failed", http.StatusInternalServerError)
+	}
+}
+
+// writeEditResult responds with the edited index and the new element count.
+func writeEditResult(w http.ResponseWriter, status, idx, count int, logger *slog.Logger) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(struct {
+		Index int `json:"index"`
+		Count int `json:"count"`
+	}{idx, count}); err != nil {
+		logger.Error("write response", "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminElements(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	t.Run("add appends compacted element", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/elements", strings.NewReader("{ \"msg\": \"new\" }\n"))
+		rec := httptest.NewRecorder()
+
+		handlers.AddElement(store, logger).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"index":1,"count":2}`, rec.Body.String())
+		assert.Equal(t, `{"msg":"new"}`, string(store.Elements()[1]))
+	})
+
+	t.Run("add rejects invalid JSON", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/elements", strings.NewReader(`{"msg":`))
+		rec := httptest.NewRecorder()
+
+		handlers.AddElement(store, logger).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Len(t, store.Elements(), 1)
+	})
+
+	t.Run("add rejects oversized body", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		body := `"` + strings.Repeat("x", 2<<20) + `"`
+		req := httptest.NewRequest(http.MethodPost, "/admin/elements", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handlers.AddElement(store, logger).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("update replaces element", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)})
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/elements/1", strings.NewReader(`"z"`))
+		req.SetPathValue("nr", "1")
+		rec := httptest.NewRecorder()
+
+		handlers.UpdateElement(store, logger).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"index":1,"count":2}`, rec.Body.String())
+		assert.Equal(t, `"z"`, string(store.Elements()[1]))
+	})
+
+	t.Run("update reports missing index", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/elements/5", strings.NewReader(`"z"`))
+		req.SetPathValue("nr", "5")
+		rec := httptest.NewRecorder()
+
+		handlers.UpdateElement(store, logger).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("delete removes element", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`), []byte(`"b"`)})
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/elements/0", nil)
+		req.SetPathValue("nr", "0")
+		rec := httptest.NewRecorder()
+
+		handlers.DeleteElement(store, logger).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, data.Elements{[]byte(`"b"`)}, store.Elements())
+	})
+
+	t.Run("delete refuses last element", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/elements/0", nil)
+		req.SetPathValue("nr", "0")
+		rec := httptest.NewRecorder()
+
+		handlers.DeleteElement(store, logger).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("delete rejects invalid index", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/elements/x", nil)
+		req.SetPathValue("nr", "x")
+		rec := httptest.NewRecorder()
+
+		handlers.DeleteElement(store, logger).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// datasetInfo describes one entry of the dataset listing.
+type datasetInfo struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Datasets returns a handler that lists the named datasets with their current
+// element counts.
+func Datasets(
+	datasets []data.Dataset,
+	logger *slog.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		infos := make([]datasetInfo, 0, len(datasets))
+		for _, ds := range datasets {
+			infos = append(infos, datasetInfo{Name: ds.Name, Count: len(ds.Store.Elements())})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(infos); err != nil {
+			logger.Error("write response", "error", err)
+		}
+	}
+}
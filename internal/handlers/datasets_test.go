@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasets(t *testing.T) {
+	t.Parallel()
+
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	t.Run("lists datasets with counts", func(t *testing.T) {
+		t.Parallel()
+
+		datasets := []data.Dataset{
+			{Name: "jokes", Store: newStore(t, data.Elements{[]byte(`1`), []byte(`2`)})},
+			{Name: "quotes", Store: newStore(t, data.Elements{[]byte(`"q"`)})},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/datasets", nil)
+		w := httptest.NewRecorder()
+
+		handlers.Datasets(datasets, logger).ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close() // nolint:errcheck
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.JSONEq(t, `[{"name":"jokes","count":2},{"name":"quotes","count":1}]`, w.Body.String())
+	})
+
+	t.Run("returns empty list without datasets", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/datasets", nil)
+		w := httptest.NewRecorder()
+
+		handlers.Datasets(nil, logger).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+}
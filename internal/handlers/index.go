@@ -4,24 +4,59 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
 )
 
-// IndexElement returns a handler that responds with the JSON element at the
-// provided index in the in-memory list.
+// IndexOptions configures the IndexElement handler.
+type IndexOptions struct {
+	Renderer *render.Renderer // Renders elements in the negotiated format (nil = built-in rendering)
+	MaxAge   time.Duration    // Cache-Control max-age (0 = clients must revalidate)
+	Private  bool             // Responses require credentials and must not be stored by shared caches
+}
+
+// cacheControl returns the Cache-Control value for index responses.
+func (o IndexOptions) cacheControl() string {
+	if o.MaxAge <= 0 {
+		return "no-cache"
+	}
+	return cacheScope(o.Private) + ", max-age=" + strconv.Itoa(int(o.MaxAge.Seconds()))
+}
+
+// cacheScope returns the Cache-Control directive that keeps responses
+// requiring credentials out of shared caches.
+func cacheScope(private bool) string {
+	if private {
+		return "private"
+	}
+	return "public"
+}
+
+// IndexElement returns a handler that responds with the element at the
+// provided index in the element set currently held by store, rendered in the
+// format negotiated via "format" or the Accept header. Responses carry a
+// strong ETag and answer a matching If-None-Match with 304.
 func IndexElement(
-	elements data.Elements,
+	store *data.Store,
+	opts IndexOptions,
 	logger *slog.Logger,
 ) http.HandlerFunc {
-	if len(elements) == 0 {
-		// return a handler that returns an error. Faster than check for len on each request.
-		return func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, ok := negotiateFormat(w, r, logger)
+		if !ok {
+			return
+		}
+
+		elements := store.Elements()
+		if len(elements) == 0 {
 			logger.Error("no elements available")
 			http.Error(w, "no elements available", http.StatusInternalServerError)
+			return
 		}
-	}
-	return func(w http.ResponseWriter, r *http.Request) {
+
 		rawIndex := r.PathValue("nr")
 		idx, err := strconv.Atoi(rawIndex)
 		if err != nil || idx < 0 {
@@ -29,19 +64,46 @@ func IndexElement(
 			http.Error(w, "invalid index", http.StatusBadRequest)
 			return
 		}
-		if idx >= len(elements) {
+
+		pick, err := store.Index(idx)
+		if err != nil {
 			logger.Warn("index out of range", "index", idx, "max", len(elements)-1)
 			http.Error(w, "index out of range", http.StatusBadRequest)
 			return
 		}
 
-		elem := elements[idx]
-		logger.Debug("index element", "index", idx, "element", string(elem))
+		etag := representationETag(pick.ETag, format)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", opts.cacheControl())
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		logger.Debug("index element", "index", idx, "element", string(pick.Element))
+
+		writeElement(w, r, opts.Renderer, format, pick, logger)
+	}
+}
+
+// representationETag derives the entity tag of elem rendered in format from
+// the element's own entity tag, so every representation has a distinct tag.
+func representationETag(etag string, format render.Format) string {
+	if format == render.FormatJSON {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + string(format) + `"`
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write(elem); err != nil {
-			logger.Error("write response", "error", err)
+// etagMatches reports whether the If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
 		}
 	}
+	return false
 }
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/handlers"
@@ -32,7 +33,7 @@ func TestIndexElement(t *testing.T) {
 		req.SetPathValue("nr", "1")
 		w := httptest.NewRecorder()
 
-		handler := handlers.IndexElement(elements, logger)
+		handler := handlers.IndexElement(newStore(t, elements), handlers.IndexOptions{}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -54,7 +55,7 @@ func TestIndexElement(t *testing.T) {
 		req.SetPathValue("nr", "nope")
 		w := httptest.NewRecorder()
 
-		handler := handlers.IndexElement(elements, logger)
+		handler := handlers.IndexElement(newStore(t, elements), handlers.IndexOptions{}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -75,7 +76,7 @@ func TestIndexElement(t *testing.T) {
 		req.SetPathValue("nr", "3")
 		w := httptest.NewRecorder()
 
-		handler := handlers.IndexElement(elements, logger)
+		handler := handlers.IndexElement(newStore(t, elements), handlers.IndexOptions{}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -93,7 +94,7 @@ func TestIndexElement(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
 		w := httptest.NewRecorder()
 
-		handler := handlers.IndexElement(elements, logger)
+		handler := handlers.IndexElement(newStore(t, elements), handlers.IndexOptions{}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -102,4 +103,117 @@ func TestIndexElement(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 		assert.Equal(t, "no elements available\n", w.Body.String())
 	})
+
+	t.Run("sets caching headers and answers conditional requests", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`{"msg":"first"}`)})
+		handler := handlers.IndexElement(store, handlers.IndexOptions{MaxAge: time.Hour}, logger)
+
+		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+		assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+		assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+
+		req = httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		req.Header.Set("If-None-Match", `"other", W/`+etag)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		// Other representations carry their own entity tag.
+		req = httptest.NewRequest(http.MethodGet, "/index/0?format=text", nil)
+		req.SetPathValue("nr", "0")
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("keeps private responses out of shared caches", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		w := httptest.NewRecorder()
+
+		opts := handlers.IndexOptions{MaxAge: time.Minute, Private: true}
+		handlers.IndexElement(newStore(t, data.Elements{[]byte(`1`)}), opts, logger).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("requires revalidation without max age", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		w := httptest.NewRecorder()
+
+		handlers.IndexElement(newStore(t, data.Elements{[]byte(`1`)}), handlers.IndexOptions{}, logger).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("serves pre-compressed JSON variants", func(t *testing.T) {
+		t.Parallel()
+
+		large := []byte(`{"text":"` + strings.Repeat("ab", 100) + `"}`)
+		store, err := data.NewStore(data.Elements{large}, data.Options{CompressMinSize: 64})
+		require.NoError(t, err)
+		pick, err := store.Index(0)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		handler := handlers.IndexElement(store, handlers.IndexOptions{}, logger)
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, []string{"Accept", "Accept-Encoding"}, w.Header().Values("Vary"))
+		assert.Equal(t, "W/"+pick.ETag, w.Header().Get("ETag"))
+		assert.Equal(t, pick.Variants["gzip"], w.Body.Bytes())
+
+		// The weak tag still revalidates.
+		req = httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("renders XML on request", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/index/0", nil)
+		req.SetPathValue("nr", "0")
+		req.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+
+		handlers.IndexElement(newStore(t, data.Elements{[]byte(`{"msg":"first"}`)}), handlers.IndexOptions{}, logger).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<element><msg>first</msg></element>")
+	})
 }
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
+)
+
+// PeriodOptions configures the element-of-the-period handlers.
+type PeriodOptions struct {
+	Location *time.Location   // Time zone periods are aligned to (nil = local time)
+	Renderer *render.Renderer // Renders elements in the negotiated format (nil = built-in rendering)
+	Now      func() time.Time // Clock (nil = time.Now)
+	Private  bool             // Responses require credentials and must not be stored by shared caches
+}
+
+// now returns the current time in the configured location.
+func (o PeriodOptions) now() time.Time {
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	return now().In(o.location())
+}
+
+// location returns the configured location or time.Local.
+func (o PeriodOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// DailyElement returns a handler that responds with the element of the
+// calendar day, or of the day given as "date" (YYYY-MM-DD).
+func DailyElement(store *data.Store, opts PeriodOptions, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		day := opts.now()
+		if raw := r.URL.Query().Get("date"); raw != "" {
+			var err error
+			if day, err = time.ParseInLocation(time.DateOnly, raw, opts.location()); err != nil {
+				logger.Warn("invalid date", "date", raw, "error", err)
+				http.Error(w, "invalid date", http.StatusBadRequest)
+				return
+			}
+		}
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+		writePeriodElement(w, r, store, "day", start, start.AddDate(0, 0, 1), opts, logger)
+	}
+}
+
+// HourlyElement returns a handler that responds with the element of the
+// current hour.
+func HourlyElement(store *data.Store, opts PeriodOptions, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := opts.now()
+		start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+		writePeriodElement(w, r, store, "hour", start, start.Add(time.Hour), opts, logger)
+	}
+}
+
+// PeriodElement returns a handler that responds with the element of the
+// current period of the "duration" path value (e.g. 15m or 6h). Periods are
+// aligned to midnight of the configured time zone's offset.
+func PeriodElement(store *data.Store, opts PeriodOptions, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := r.PathValue("duration")
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < time.Second {
+			logger.Warn("invalid period", "duration", raw, "error", err)
+			http.Error(w, "invalid period: expected a duration of at least 1s", http.StatusBadRequest)
+			return
+		}
+
+		now := opts.now()
+		_, offset := now.Zone()
+		shift := time.Duration(offset) * time.Second
+		start := now.Add(shift).Truncate(d).Add(-shift)
+		writePeriodElement(w, r, store, d.String(), start, start.Add(d), opts, logger)
+	}
+}
+
+// writePeriodElement responds with the element picked deterministically for
+// the period [start, end) and lets caches keep it until the period ends.
+func writePeriodElement(
+	w http.ResponseWriter,
+	r *http.Request,
+	store *data.Store,
+	period string,
+	start, end time.Time,
+	opts PeriodOptions,
+	logger *slog.Logger,
+) {
+	format, ok := negotiateFormat(w, r, logger)
+	if !ok {
+		return
+	}
+
+	seed := fmt.Sprintf("%s@%d", period, start.Unix())
+	pick, err := store.Random(seededRand(seed))
+	if err != nil {
+		writePickError(w, err, logger)
+		return
+	}
+
+	logger.Debug("period element", "period", period, "start", start, "index", pick.Index)
+
+	maxAge := max(int(end.Sub(opts.now()).Seconds()), 0)
+	w.Header().Set("Cache-Control", cacheScope(opts.Private)+", max-age="+strconv.Itoa(maxAge))
+	w.Header().Set("Expires", end.UTC().Format(http.TimeFormat))
+	writeElement(w, r, opts.Renderer, format, pick, logger)
+}
@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodElements(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	require.NoError(t, err)
+
+	elements := make(data.Elements, 0, 50)
+	for i := range 50 {
+		elements = append(elements, []byte(strconv.Itoa(i)))
+	}
+
+	// at returns options with a clock fixed to the given Zurich wall time.
+	at := func(value string) handlers.PeriodOptions {
+		now, err := time.ParseInLocation(time.DateTime, value, zurich)
+		require.NoError(t, err)
+		return handlers.PeriodOptions{Location: zurich, Now: func() time.Time { return now }}
+	}
+
+	serve := func(t *testing.T, handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("daily is stable within a day", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		morning := serve(t, handlers.DailyElement(store, at("2026-10-18 00:30:00"), logger), httptest.NewRequest(http.MethodGet, "/daily", nil))
+		evening := serve(t, handlers.DailyElement(store, at("2026-10-18 23:30:00"), logger), httptest.NewRequest(http.MethodGet, "/daily", nil))
+
+		require.Equal(t, http.StatusOK, morning.Code)
+		assert.Equal(t, morning.Body.String(), evening.Body.String())
+		assert.Equal(t, "public, max-age=84600", morning.Header().Get("Cache-Control"))
+		assert.Equal(t, "public, max-age=1800", evening.Header().Get("Cache-Control"))
+		assert.Equal(t, "Sun, 18 Oct 2026 22:00:00 GMT", morning.Header().Get("Expires"))
+	})
+
+	t.Run("daily changes across days", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		handler := handlers.DailyElement(store, at("2026-10-18 12:00:00"), logger)
+
+		bodies := map[string]bool{}
+		for day := 1; day <= 10; day++ {
+			req := httptest.NewRequest(http.MethodGet, "/daily?date=2026-10-"+strconv.Itoa(10+day), nil)
+			rec := serve(t, handler, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			bodies[rec.Body.String()] = true
+		}
+		assert.Greater(t, len(bodies), 1)
+	})
+
+	t.Run("daily honours date parameter", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		today := serve(t, handlers.DailyElement(store, at("2026-10-18 08:00:00"), logger), httptest.NewRequest(http.MethodGet, "/daily", nil))
+		explicit := serve(t, handlers.DailyElement(store, at("2026-01-01 08:00:00"), logger), httptest.NewRequest(http.MethodGet, "/daily?date=2026-10-18", nil))
+
+		assert.Equal(t, today.Body.String(), explicit.Body.String())
+		assert.Equal(t, "Sun, 18 Oct 2026 22:00:00 GMT", explicit.Header().Get("Expires"))
+
+		rec := serve(t, handlers.DailyElement(store, at("2026-10-18 08:00:00"), logger), httptest.NewRequest(http.MethodGet, "/daily?date=18.10.2026", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("hourly expires at the next hour", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		first := serve(t, handlers.HourlyElement(store, at("2026-10-18 14:05:00"), logger), httptest.NewRequest(http.MethodGet, "/hourly", nil))
+		second := serve(t, handlers.HourlyElement(store, at("2026-10-18 14:55:00"), logger), httptest.NewRequest(http.MethodGet, "/hourly", nil))
+
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "public, max-age=3300", first.Header().Get("Cache-Control"))
+		assert.Equal(t, "Sun, 18 Oct 2026 13:00:00 GMT", first.Header().Get("Expires"))
+	})
+
+	t.Run("private responses are not shared", func(t *testing.T) {
+		t.Parallel()
+
+		opts := at("2026-10-18 14:05:00")
+		opts.Private = true
+		rec := serve(t, handlers.HourlyElement(newStore(t, elements), opts, logger), httptest.NewRequest(http.MethodGet, "/hourly", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "private, max-age=3300", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("period aligns to local midnight", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		req := httptest.NewRequest(http.MethodGet, "/period/6h", nil)
+		req.SetPathValue("duration", "6h")
+
+		rec := serve(t, handlers.PeriodElement(store, at("2026-10-18 13:00:00"), logger), req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "public, max-age=18000", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "Sun, 18 Oct 2026 16:00:00 GMT", rec.Header().Get("Expires"))
+	})
+
+	t.Run("period rejects invalid durations", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, elements)
+		for _, raw := range []string{"soon", "500ms", "-1h"} {
+			req := httptest.NewRequest(http.MethodGet, "/period/"+raw, nil)
+			req.SetPathValue("duration", raw)
+
+			rec := serve(t, handlers.PeriodElement(store, at("2026-10-18 13:00:00"), logger), req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, raw)
+		}
+	})
+}
@@ -1,38 +1,176 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"math/rand"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
 )
 
-// rnd is a local pseudo-random number generator seeded once at startup.
-var rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+// RandomOptions configures the RandomElement handler.
+type RandomOptions struct {
+	MaxCount int              // Maximum number of elements returned for ?count=N
+	OnPick   func(index int)  // Called with the index of every picked element (optional)
+	Renderer *render.Renderer // Renders elements in the negotiated format (nil = built-in rendering)
+	Decks    *Decks           // Deals from per-client shuffle bags instead of picking randomly (optional)
+	Dataset  string           // Dataset name separating the client decks
+}
 
-// RandomElement returns a handler that responds with a single random JSON element
-// from the provided in-memory list.
+// RandomElement returns a handler that responds with a single random element
+// from the element set currently held by store, honouring configured weights.
+// The optional "seed" query parameter makes the pick deterministic, "filter"
+// (repeatable, ANDed) restricts it to matching object elements, and "count"
+// (with "unique") returns a list of up to opts.MaxCount elements. The
+// representation is negotiated via "format" or the Accept header. With
+// opts.Decks set, unseeded and unfiltered requests are dealt from the client's
+// shuffle bag so no element repeats before all were seen.
 func RandomElement(
-	elements data.Elements,
+	store *data.Store,
+	rnd *rand.Rand,
+	opts RandomOptions,
 	logger *slog.Logger,
 ) http.HandlerFunc {
-	if len(elements) == 0 {
-		// return a handler that returns an error. Faster than check for len on each request.
-		return func(w http.ResponseWriter, r *http.Request) {
-			logger.Error("no elements available")
-			http.Error(w, "no elements available", http.StatusInternalServerError)
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Every response is a fresh pick, so shared caches must never store it.
+		w.Header().Set("Cache-Control", "no-store")
+
+		query := r.URL.Query()
+
+		format, ok := negotiateFormat(w, r, logger)
+		if !ok {
+			return
+		}
+
+		pickRnd := rnd
+		if seed := query.Get("seed"); seed != "" {
+			pickRnd = seededRand(seed)
+		}
+
+		filters, err := data.ParseFilters(query["filter"])
+		if err != nil {
+			logger.Warn("invalid filter", "filter", query["filter"], "error", err)
+			http.Error(w, "invalid filter", http.StatusBadRequest)
+			return
+		}
+
+		var deck *data.Deck
+		if opts.Decks != nil && !query.Has("seed") && len(filters) == 0 {
+			deck = opts.Decks.deck(opts.Dataset, clientID(w, r))
 		}
+
+		if query.Has("count") {
+			randomElements(w, format, query.Get("count"), query.Get("unique"), filters, store, deck, pickRnd, opts, logger)
+			return
+		}
+
+		pick, err := randomElement(store, deck, pickRnd, filters)
+		if err != nil {
+			writePickError(w, err, logger)
+			return
+		}
+		opts.observe(pick)
+
+		logger.Debug("random element", "index", pick.Index, "element", string(pick.Element))
+
+		writeElement(w, r, opts.Renderer, format, pick, logger)
 	}
-	return func(w http.ResponseWriter, r *http.Request) {
-		elem := elements[rnd.Intn(len(elements))]
-		logger.Debug("random element", "element", string(elem))
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write(elem); err != nil {
-			logger.Error("write response", "error", err)
+// randomElement picks one element, dealing it from deck if non-nil.
+func randomElement(store *data.Store, deck *data.Deck, rnd *rand.Rand, filters []data.Filter) (data.Pick, error) {
+	if deck == nil {
+		return store.Random(rnd, filters...)
+	}
+	picks, err := store.Deal(rnd, deck, 1, false)
+	if err != nil {
+		return data.Pick{}, err
+	}
+	return picks[0], nil
+}
+
+// randomElements responds with a list of rawCount random elements, dealt from
+// deck if non-nil.
+func randomElements(
+	w http.ResponseWriter,
+	format render.Format,
+	rawCount, rawUnique string,
+	filters []data.Filter,
+	store *data.Store,
+	deck *data.Deck,
+	rnd *rand.Rand,
+	opts RandomOptions,
+	logger *slog.Logger,
+) {
+	count, err := strconv.Atoi(rawCount)
+	if err != nil || count < 1 {
+		logger.Warn("invalid count", "count", rawCount, "error", err)
+		http.Error(w, "invalid count", http.StatusBadRequest)
+		return
+	}
+	if count > opts.MaxCount {
+		logger.Warn("count exceeds maximum", "count", count, "max", opts.MaxCount)
+		http.Error(w, fmt.Sprintf("count exceeds maximum of %d", opts.MaxCount), http.StatusBadRequest)
+		return
+	}
+
+	unique := false
+	if rawUnique != "" {
+		if unique, err = strconv.ParseBool(rawUnique); err != nil {
+			logger.Warn("invalid unique", "unique", rawUnique, "error", err)
+			http.Error(w, "invalid unique", http.StatusBadRequest)
+			return
 		}
 	}
+
+	var picks []data.Pick
+	if deck != nil {
+		picks, err = store.Deal(rnd, deck, count, unique)
+	} else {
+		picks, err = store.RandomN(rnd, count, unique, filters...)
+	}
+	if err != nil {
+		writePickError(w, err, logger)
+		return
+	}
+	for _, pick := range picks {
+		opts.observe(pick)
+	}
+
+	logger.Debug("random elements", "count", count, "unique", unique)
+
+	elems := make([]data.Element, 0, len(picks))
+	for _, pick := range picks {
+		elems = append(elems, pick.Element)
+	}
+	writeElements(w, opts.Renderer, format, elems, logger)
+}
+
+// observe reports pick to the OnPick callback, if any.
+func (o RandomOptions) observe(pick data.Pick) {
+	if o.OnPick != nil {
+		o.OnPick(pick.Index)
+	}
+}
+
+// writePickError maps a store pick error to an HTTP response.
+func writePickError(w http.ResponseWriter, err error, logger *slog.Logger) {
+	switch {
+	case errors.Is(err, data.ErrNoElements):
+		logger.Error("no elements available")
+		http.Error(w, "no elements available", http.StatusInternalServerError)
+	case errors.Is(err, data.ErrNoMatch):
+		logger.Warn("no elements match filter")
+		http.Error(w, "no elements match filter", http.StatusNotFound)
+	case errors.Is(err, data.ErrNotEnoughElements):
+		logger.Warn("count exceeds available elements", "error", err)
+		http.Error(w, "count exceeds available elements", http.StatusBadRequest)
+	default:
+		logger.Error("pick random element", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
 }
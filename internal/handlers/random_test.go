@@ -1,14 +1,17 @@
 package handlers_test
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/gi8lino/randomapi/internal/render"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,7 +34,7 @@ func TestRandomElement(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/random", nil)
 		w := httptest.NewRecorder()
 
-		handler := handlers.RandomElement(elements, logger)
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 100}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -39,6 +42,7 @@ func TestRandomElement(t *testing.T) {
 
 		require.Equal(t, http.StatusOK, res.StatusCode)
 		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
 
 		body := w.Body.String()
 
@@ -51,6 +55,162 @@ func TestRandomElement(t *testing.T) {
 		assert.True(t, validBodies[body], "unexpected body: %q", body)
 	})
 
+	t.Run("seed query parameter makes pick deterministic", func(t *testing.T) {
+		t.Parallel()
+
+		elements := make(data.Elements, 0, 50)
+		for i := range 50 {
+			elements = append(elements, []byte(strconv.Itoa(i)))
+		}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 100}, logger)
+
+		pick := func(seed string) string {
+			req := httptest.NewRequest(http.MethodGet, "/random?seed="+seed, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			return w.Body.String()
+		}
+
+		first := pick("abc")
+		for range 10 {
+			assert.Equal(t, first, pick("abc"))
+		}
+
+		seen := map[string]bool{}
+		for _, seed := range []string{"a", "b", "c", "d", "e", "f"} {
+			seen[pick(seed)] = true
+		}
+		assert.Greater(t, len(seen), 1, "different seeds should not all pick the same element")
+	})
+
+	t.Run("seeded generator repeats sequence", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`1`), []byte(`2`), []byte(`3`), []byte(`4`)}
+		sequence := func() []string {
+			handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(42), handlers.RandomOptions{MaxCount: 100}, logger)
+			var bodies []string
+			for range 10 {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/random", nil))
+				bodies = append(bodies, w.Body.String())
+			}
+			return bodies
+		}
+
+		assert.Equal(t, sequence(), sequence())
+	})
+
+	t.Run("count returns JSON array", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`{"msg":"first"}`), []byte(`"second"`), []byte(`3`)}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		req := httptest.NewRequest(http.MethodGet, "/random?count=5", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var got []json.RawMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Len(t, got, 5)
+	})
+
+	t.Run("count with unique returns distinct elements", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`1`), []byte(`2`), []byte(`3`), []byte(`4`)}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		req := httptest.NewRequest(http.MethodGet, "/random?count=4&unique=true", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var got []int
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.ElementsMatch(t, []int{1, 2, 3, 4}, got)
+	})
+
+	t.Run("count rejects invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`1`), []byte(`2`)}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		tests := map[string]string{
+			"/random?count=abc":            "invalid count\n",
+			"/random?count=0":              "invalid count\n",
+			"/random?count=11":             "count exceeds maximum of 10\n",
+			"/random?count=2&unique=maybe": "invalid unique\n",
+			"/random?count=3&unique=true":  "count exceeds available elements\n",
+		}
+		for target, body := range tests {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+			assert.Equal(t, body, w.Body.String(), target)
+		}
+	})
+
+	t.Run("filter restricts picks", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{
+			[]byte(`{"type":"general"}`),
+			[]byte(`{"type":"programming"}`),
+		}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		for range 20 {
+			req := httptest.NewRequest(http.MethodGet, "/random?filter=type:programming", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, `{"type":"programming"}`, w.Body.String())
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/random?filter=type:programming&count=3", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `[{"type":"programming"},{"type":"programming"},{"type":"programming"}]`, w.Body.String())
+	})
+
+	t.Run("filter errors", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`{"type":"general"}`)}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		tests := []struct {
+			target string
+			status int
+			body   string
+		}{
+			{target: "/random?filter=type", status: http.StatusBadRequest, body: "invalid filter\n"},
+			{target: "/random?filter=type:general&filter=lang:de", status: http.StatusNotFound, body: "no elements match filter\n"},
+			{target: "/random?filter=type:other&count=2", status: http.StatusNotFound, body: "no elements match filter\n"},
+		}
+		for _, tc := range tests {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code, tc.target)
+			assert.Equal(t, tc.body, w.Body.String(), tc.target)
+		}
+	})
+
 	t.Run("returns 500 when no elements available", func(t *testing.T) {
 		t.Parallel()
 
@@ -59,7 +219,7 @@ func TestRandomElement(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/random", nil)
 		w := httptest.NewRecorder()
 
-		handler := handlers.RandomElement(elements, logger)
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 100}, logger)
 		handler.ServeHTTP(w, req)
 
 		res := w.Result()
@@ -69,4 +229,75 @@ func TestRandomElement(t *testing.T) {
 		// http.Error adds a trailing newline
 		assert.Equal(t, "no elements available\n", w.Body.String())
 	})
+
+	t.Run("reports picked indexes", func(t *testing.T) {
+		t.Parallel()
+
+		elements := data.Elements{[]byte(`"a"`), []byte(`"b"`), []byte(`"c"`)}
+		var picked []int
+		opts := handlers.RandomOptions{MaxCount: 10, OnPick: func(idx int) { picked = append(picked, idx) }}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), opts, logger)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random?filter=x:y", nil))
+		assert.Empty(t, picked)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random?count=3&unique=true", nil))
+
+		require.Len(t, picked, 4)
+		assert.ElementsMatch(t, []int{0, 1, 2}, picked[1:])
+	})
+
+	t.Run("negotiates representation", func(t *testing.T) {
+		t.Parallel()
+
+		handler := handlers.RandomElement(newStore(t, data.Elements{[]byte(`"knock knock"`)}), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10}, logger)
+
+		req := httptest.NewRequest(http.MethodGet, "/random", nil)
+		req.Header.Set("Accept", "text/plain")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+		assert.Equal(t, "knock knock", rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random?format=html&count=2", nil))
+
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, 2, strings.Count(rec.Body.String(), `<div class="randomapi-element">knock knock</div>`))
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random?format=csv", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("renders through template", func(t *testing.T) {
+		t.Parallel()
+
+		renderer, err := render.New(render.Templates{Text: "{{.setup}} - {{.punchline}}"})
+		require.NoError(t, err)
+
+		elements := data.Elements{[]byte(`{"setup":"Knock knock","punchline":"Who's there?"}`)}
+		handler := handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10, Renderer: renderer}, logger)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random?format=text", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Knock knock - Who's there?", rec.Body.String())
+	})
+}
+
+// newStore returns a store serving elements with default options.
+func newStore(t *testing.T, elements data.Elements) *data.Store {
+	t.Helper()
+
+	store, err := data.NewStore(elements, data.Options{})
+	require.NoError(t, err)
+
+	return store
 }
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// Readiness tracks whether the app is shutting down.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// StartDrain marks the app as shutting down; /readyz reports not ready from
+// then on so load balancers stop sending new requests.
+func (r *Readiness) StartDrain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether StartDrain was called.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// readyStatus is the JSON body returned by /readyz.
+type readyStatus struct {
+	Status   string         `json:"status"`
+	Reason   string         `json:"reason,omitempty"`
+	Version  string         `json:"version"`
+	Datasets []readyDataset `json:"datasets"`
+}
+
+// readyDataset describes the state of one served dataset.
+type readyDataset struct {
+	Name                string    `json:"name"`
+	Source              string    `json:"source"`
+	Count               int       `json:"count"`
+	LoadedAt            time.Time `json:"loaded_at"`
+	ReloadFailures      uint64    `json:"reload_failures"`
+	ConsecutiveFailures uint64    `json:"consecutive_reload_failures"`
+}
+
+// Readyz returns the HTTP handler for the /readyz endpoint. It responds with
+// 503 while readiness is draining, when a dataset holds no elements, or when
+// a dataset failed to reload maxFailures times in a row (0 disables the check).
+func Readyz(
+	version string,
+	datasets []data.Dataset,
+	readiness *Readiness,
+	maxFailures uint64,
+	logger *slog.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := readyStatus{
+			Version:  version,
+			Datasets: make([]readyDataset, 0, len(datasets)),
+		}
+
+		if readiness.Draining() {
+			status.Reason = "shutting down"
+		}
+		for _, ds := range datasets {
+			info := readyDataset{
+				Name:                ds.Name,
+				Source:              redactSource(ds.Path),
+				Count:               len(ds.Store.Elements()),
+				LoadedAt:            ds.Store.LoadedAt(),
+				ReloadFailures:      ds.Store.ReloadFailures(),
+				ConsecutiveFailures: ds.Store.ConsecutiveReloadFailures(),
+			}
+			status.Datasets = append(status.Datasets, info)
+
+			if status.Reason != "" {
+				continue
+			}
+			switch {
+			case info.Count == 0:
+				status.Reason = fmt.Sprintf("dataset %q has no elements", ds.Name)
+			case maxFailures > 0 && info.ConsecutiveFailures >= maxFailures:
+				status.Reason = fmt.Sprintf("dataset %q failed to reload %d times in a row", ds.Name, info.ConsecutiveFailures)
+			}
+		}
+
+		code := http.StatusOK
+		status.Status = "ready"
+		if status.Reason != "" {
+			code = http.StatusServiceUnavailable
+			status.Status = "not ready"
+			logger.Debug("not ready", "reason", status.Reason)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Error("write response", "error", err)
+		}
+	}
+}
+
+// redactSource hides credentials embedded in URL sources.
+func redactSource(source string) string {
+	if !data.IsURL(source) {
+		return source
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	return u.Redacted()
+}
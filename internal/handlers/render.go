@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gi8lino/randomapi/internal/compress"
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
+)
+
+// negotiateFormat picks the response format from the "format" query parameter
+// or the Accept header. It responds with 400 for an unknown "format".
+func negotiateFormat(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (render.Format, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	format, err := render.Negotiate(r)
+	if err != nil {
+		logger.Warn("invalid format", "format", r.URL.Query().Get("format"), "error", err)
+		http.Error(w, "invalid format", http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// writeElement responds with the picked element rendered in format. JSON
+// responses use the element's pre-compressed variants when the client accepts
+// one of their encodings.
+func writeElement(
+	w http.ResponseWriter,
+	r *http.Request,
+	renderer *render.Renderer,
+	format render.Format,
+	pick data.Pick,
+	logger *slog.Logger,
+) {
+	if format == render.FormatJSON && len(pick.Variants) > 0 {
+		compress.Vary(w.Header())
+
+		offered := make([]compress.Encoding, 0, len(pick.Variants))
+		for _, enc := range compress.Encodings {
+			if _, ok := pick.Variants[enc]; ok {
+				offered = append(offered, enc)
+			}
+		}
+		if enc := compress.Negotiate(r, offered); enc != "" {
+			w.Header().Set("Content-Encoding", string(enc))
+			compress.Weaken(w.Header())
+			writeRendered(w, format, pick.Variants[enc], nil, logger)
+			return
+		}
+	}
+
+	body, err := renderer.Element(format, pick.Element)
+	writeRendered(w, format, body, err, logger)
+}
+
+// writeElements responds with elems rendered as a list in format.
+func writeElements(w http.ResponseWriter, renderer *render.Renderer, format render.Format, elems []data.Element, logger *slog.Logger) {
+	body, err := renderer.Elements(format, elems)
+	writeRendered(w, format, body, err, logger)
+}
+
+// writeRendered writes a rendered body or the rendering error.
+func writeRendered(w http.ResponseWriter, format render.Format, body []byte, err error, logger *slog.Logger) {
+	if err != nil {
+		logger.Error("render element", "format", format, "error", err)
+		http.Error(w, "render element failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		logger.Error("write response", "error", err)
+	}
+}
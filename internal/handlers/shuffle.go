@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gi8lino/randomapi/internal/data"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// ClientIDHeader identifies a client for shuffle-bag selection.
+const ClientIDHeader = "X-Client-ID"
+
+// ClientCookie identifies a client for shuffle-bag selection when the
+// X-Client-ID header is not sent. It is set on the first response.
+const ClientCookie = "randomapi_client"
+
+// maxClientIDLength bounds client IDs accepted from requests.
+const maxClientIDLength = 128
+
+// clientCookieMaxAge keeps the client cookie for a year.
+const clientCookieMaxAge = 365 * 24 * 60 * 60
+
+// Decks keeps the shuffle-bag decks of the most recently seen clients.
+type Decks struct {
+	cache *lru.Cache[string, *data.Deck]
+}
+
+// NewDecks returns Decks remembering up to size client decks per process.
+func NewDecks(size int) (*Decks, error) {
+	cache, err := lru.New[string, *data.Deck](size)
+	if err != nil {
+		return nil, err
+	}
+	return &Decks{cache: cache}, nil
+}
+
+// deck returns the deck of client for dataset, creating it if needed.
+func (d *Decks) deck(dataset, client string) *data.Deck {
+	key := dataset + "\x00" + client
+	if deck, ok := d.cache.Get(key); ok {
+		return deck
+	}
+	deck := data.NewDeck()
+	if prev, ok, _ := d.cache.PeekOrAdd(key, deck); ok {
+		return prev
+	}
+	return deck
+}
+
+// clientID returns the ID from the X-Client-ID header or the client cookie.
+// Clients without either get a new ID in a cookie.
+func clientID(w http.ResponseWriter, r *http.Request) string {
+	if id := r.Header.Get(ClientIDHeader); validClientID(id) {
+		return id
+	}
+	if c, err := r.Cookie(ClientCookie); err == nil && validClientID(c.Value) {
+		return c.Value
+	}
+
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // never returns an error
+	id := hex.EncodeToString(b[:])
+	http.SetCookie(w, &http.Cookie{
+		Name:     ClientCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   clientCookieMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// validClientID reports whether id is non-empty, bounded and printable ASCII.
+func validClientID(id string) bool {
+	if id == "" || len(id) > maxClientIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShuffleBag(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	elements := data.Elements{[]byte(`"a"`), []byte(`"b"`), []byte(`"c"`), []byte(`"d"`)}
+
+	newHandler := func(t *testing.T) http.Handler {
+		t.Helper()
+
+		decks, err := handlers.NewDecks(10)
+		require.NoError(t, err)
+		return handlers.RandomElement(newStore(t, elements), handlers.NewRand(0), handlers.RandomOptions{MaxCount: 10, Decks: decks}, logger)
+	}
+
+	t.Run("client header gets every element before repeats", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler(t)
+		seen := map[string]bool{}
+		for range 4 {
+			req := httptest.NewRequest(http.MethodGet, "/random", nil)
+			req.Header.Set(handlers.ClientIDHeader, "bot-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("Set-Cookie"))
+			seen[rec.Body.String()] = true
+		}
+		assert.Len(t, seen, 4)
+	})
+
+	t.Run("cookie identifies new clients", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler(t)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random?count=2", nil))
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, handlers.ClientCookie, cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+
+		req := httptest.NewRequest(http.MethodGet, "/random?count=2", nil)
+		req.AddCookie(cookies[0])
+		rec2 := httptest.NewRecorder()
+		handler.ServeHTTP(rec2, req)
+
+		assert.Empty(t, rec2.Header().Get("Set-Cookie"))
+		all := strings.Trim(rec.Body.String(), "[]") + "," + strings.Trim(rec2.Body.String(), "[]")
+		assert.ElementsMatch(t, []string{`"a"`, `"b"`, `"c"`, `"d"`}, strings.Split(all, ","))
+	})
+
+	t.Run("seeded requests bypass the deck", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler(t)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random?seed=abc", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Set-Cookie"))
+	})
+}
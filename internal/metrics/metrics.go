@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/data"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes all randomapi metric names.
+const namespace = "randomapi"
+
+// Metrics holds the Prometheus collectors exposed on /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	picks    *prometheus.CounterVec
+	datasets *datasetCollector
+}
+
+// New returns Metrics backed by a dedicated registry that also includes the
+// Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status code.",
+		}, []string{"route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "code"}),
+		picks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "random_picks_total",
+			Help:      "Number of times each element was returned by /random.",
+		}, []string{"dataset", "index"}),
+		datasets: &datasetCollector{stores: map[string]*data.Store{}},
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.picks,
+		m.datasets,
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus
+// exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records one served HTTP request.
+func (m *Metrics) ObserveRequest(route string, code int, d time.Duration) {
+	status := strconv.Itoa(code)
+	m.requests.WithLabelValues(route, status).Inc()
+	m.duration.WithLabelValues(route, status).Observe(d.Seconds())
+}
+
+// PickObserver returns a callback that counts picks of element indexes in dataset.
+func (m *Metrics) PickObserver(dataset string) func(index int) {
+	return func(index int) {
+		m.picks.WithLabelValues(dataset, strconv.Itoa(index)).Inc()
+	}
+}
+
+// RegisterDataset exposes size, last reload time and reload failures of store.
+func (m *Metrics) RegisterDataset(name string, store *data.Store) {
+	m.datasets.add(name, store)
+}
+
+// datasetCollector reads dataset gauges from the stores at scrape time.
+type datasetCollector struct {
+	mu     sync.RWMutex
+	stores map[string]*data.Store
+}
+
+var (
+	datasetElementsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataset", "elements"),
+		"Number of elements currently served.",
+		[]string{"dataset"}, nil,
+	)
+	datasetLoadedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataset", "last_reload_timestamp_seconds"),
+		"Unix time the current elements were loaded.",
+		[]string{"dataset"}, nil,
+	)
+	datasetFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataset", "reload_failures_total"),
+		"Number of failed reload attempts.",
+		[]string{"dataset"}, nil,
+	)
+)
+
+// add registers store under name.
+func (c *datasetCollector) add(name string, store *data.Store) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stores[name] = store
+}
+
+// Describe implements prometheus.Collector.
+func (c *datasetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- datasetElementsDesc
+	ch <- datasetLoadedDesc
+	ch <- datasetFailuresDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *datasetCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, store := range c.stores {
+		ch <- prometheus.MustNewConstMetric(datasetElementsDesc, prometheus.GaugeValue, float64(len(store.Elements())), name)
+		ch <- prometheus.MustNewConstMetric(datasetLoadedDesc, prometheus.GaugeValue, float64(store.LoadedAt().UnixNano())/1e9, name)
+		ch <- prometheus.MustNewConstMetric(datasetFailuresDesc, prometheus.CounterValue, float64(store.ReloadFailures()), name)
+	}
+}
@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exposes request, pick and dataset metrics", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := data.NewStore(data.Elements{[]byte(`"a"`), []byte(`"b"`)}, data.Options{})
+		require.NoError(t, err)
+		store.MarkReloadFailed()
+
+		m := metrics.New()
+		m.RegisterDataset("jokes", store)
+		m.ObserveRequest("GET /random", http.StatusOK, 5*time.Millisecond)
+		pick := m.PickObserver("jokes")
+		pick(1)
+		pick(1)
+
+		body := scrape(t, m)
+
+		assert.Contains(t, body, `randomapi_http_requests_total{code="200",route="GET /random"} 1`)
+		assert.Contains(t, body, `randomapi_http_request_duration_seconds_count{code="200",route="GET /random"} 1`)
+		assert.Contains(t, body, `randomapi_random_picks_total{dataset="jokes",index="1"} 2`)
+		assert.Contains(t, body, `randomapi_dataset_elements{dataset="jokes"} 2`)
+		assert.Contains(t, body, `randomapi_dataset_reload_failures_total{dataset="jokes"} 1`)
+		assert.Contains(t, body, `randomapi_dataset_last_reload_timestamp_seconds{dataset="jokes"}`)
+		assert.Contains(t, body, "go_goroutines")
+	})
+
+	t.Run("dataset size follows the store", func(t *testing.T) {
+		t.Parallel()
+
+		store, err := data.NewStore(data.Elements{[]byte(`"a"`)}, data.Options{})
+		require.NoError(t, err)
+
+		m := metrics.New()
+		m.RegisterDataset("default", store)
+		require.NoError(t, store.Replace(data.Elements{[]byte(`1`), []byte(`2`), []byte(`3`)}))
+
+		assert.Contains(t, scrape(t, m), `randomapi_dataset_elements{dataset="default"} 3`)
+	})
+}
+
+// scrape returns the exposition text served by m.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	return rec.Body.String()
+}
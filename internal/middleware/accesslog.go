@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// AccessLog returns middleware that logs one line per request with method,
+// path, status, bytes, duration, remote address, user agent and request ID.
+// The request ID is taken from X-Request-ID or generated, stored in the
+// request context and echoed in the response. Requests to skipPaths are
+// served without being logged.
+func AccessLog(logger *slog.Logger, skipPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			r, id := withRequestID(r)
+			w.Header().Set(RequestIDHeader, id)
+
+			sw := newStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			if slices.Contains(skipPaths, r.URL.Path) {
+				return
+			}
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Int("bytes", sw.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+				slog.String("request_id", id),
+			)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+
+	// echo responds with the request ID seen by the handler.
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(middleware.RequestID(r.Context())))
+	})
+
+	t.Run("logs request fields", func(t *testing.T) {
+		t.Parallel()
+
+		var buf strings.Builder
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		handler := middleware.AccessLog(logger)(echo)
+
+		req := httptest.NewRequest(http.MethodPost, "/random?count=2", nil)
+		req.Header.Set("User-Agent", "curl/8.0")
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, rec.Body.String())
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(buf.String()), &entry))
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, "POST", entry["method"])
+		assert.Equal(t, "/random", entry["path"])
+		assert.Equal(t, float64(http.StatusCreated), entry["status"])
+		assert.Equal(t, float64(32), entry["bytes"])
+		assert.Equal(t, "10.0.0.1:1234", entry["remote"])
+		assert.Equal(t, "curl/8.0", entry["user_agent"])
+		assert.Equal(t, id, entry["request_id"])
+		assert.Contains(t, entry, "duration")
+	})
+
+	t.Run("honours incoming request ID", func(t *testing.T) {
+		t.Parallel()
+
+		var buf strings.Builder
+		handler := middleware.AccessLog(slog.New(slog.NewTextHandler(&buf, nil)))(echo)
+
+		req := httptest.NewRequest(http.MethodGet, "/random", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "abc-123", rec.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, "abc-123", rec.Body.String())
+		assert.Contains(t, buf.String(), "request_id=abc-123")
+	})
+
+	t.Run("replaces invalid request ID", func(t *testing.T) {
+		t.Parallel()
+
+		handler := middleware.AccessLog(slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))(echo)
+
+		req := httptest.NewRequest(http.MethodGet, "/random", nil)
+		req.Header.Set(middleware.RequestIDHeader, strings.Repeat("x", 200))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Len(t, rec.Header().Get(middleware.RequestIDHeader), 32)
+	})
+
+	t.Run("skips configured paths", func(t *testing.T) {
+		t.Parallel()
+
+		var buf strings.Builder
+		handler := middleware.AccessLog(slog.New(slog.NewTextHandler(&buf, nil)), "/healthz")(echo)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Empty(t, buf.String())
+		assert.NotEmpty(t, rec.Header().Get(middleware.RequestIDHeader), "request ID is still set")
+	})
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// RequireBearerToken returns middleware that rejects requests whose
+// "Authorization: Bearer <token>" header does not match token. Tokens are
+// compared as SHA-256 digests in constant time.
+func RequireBearerToken(token string, logger *slog.Logger) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(token))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := bearerToken(r)
+			gotSum := sha256.Sum256([]byte(got))
+			if !ok || subtle.ConstantTimeCompare(gotSum[:], want[:]) != 1 {
+				logger.Warn("unauthorized request", "method", r.Method, "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="randomapi"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// APIKey is an accepted API key, stored as its SHA-256 digest, together with
+// the routes it may access.
+type APIKey struct {
+	digest [sha256.Size]byte
+	scopes []string // path patterns; empty allows every route
+}
+
+// ParseAPIKey parses an API key entry of the form "KEY [SCOPE...]". KEY is
+// the key itself or its digest as "sha256:<hex>". Each SCOPE is a request
+// path, a path prefix ending in "/*" (e.g. "/jokes/*") or "*" for all routes.
+func ParseAPIKey(entry string) (APIKey, error) {
+	fields := strings.Fields(entry)
+	if len(fields) == 0 {
+		return APIKey{}, errors.New("empty API key")
+	}
+
+	var key APIKey
+	if hexDigest, ok := strings.CutPrefix(fields[0], "sha256:"); ok {
+		digest, err := hex.DecodeString(hexDigest)
+		if err != nil || len(digest) != sha256.Size {
+			return APIKey{}, fmt.Errorf("invalid API key digest %q: expected 64 hex characters", hexDigest)
+		}
+		copy(key.digest[:], digest)
+	} else {
+		key.digest = sha256.Sum256([]byte(fields[0]))
+	}
+
+	for _, scope := range fields[1:] {
+		if scope != "*" && !strings.HasPrefix(scope, "/") {
+			return APIKey{}, fmt.Errorf("invalid API key scope %q: expected a path starting with / or *", scope)
+		}
+		key.scopes = append(key.scopes, scope)
+	}
+	return key, nil
+}
+
+// LoadAPIKeys reads API key entries from path, one per line. Empty lines and
+// lines starting with "#" are ignored.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API keys: %w", err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	var keys []APIKey
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		key, err := ParseAPIKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("read API keys: %s:%d: %w", path, line, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// allows reports whether the key may access path.
+func (k APIKey) allows(path string) bool {
+	if len(k.scopes) == 0 {
+		return true
+	}
+	for _, scope := range k.scopes {
+		if matchPath(scope, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAPIKey returns middleware that rejects requests without one of keys
+// in an "Authorization: Bearer" or X-API-Key header with 401, and requests
+// outside the key's scopes with 403. Keys are compared as SHA-256 digests in
+// constant time. Requests matching an exempt path pattern are served without
+// a key.
+func RequireAPIKey(keys []APIKey, exempt []string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if MatchPaths(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			given := r.Header.Get(APIKeyHeader)
+			if given == "" {
+				given, _ = bearerToken(r)
+			}
+			key, ok := lookupAPIKey(keys, given)
+			if given == "" || !ok {
+				logger.Warn("unauthorized request", "method", r.Method, "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="randomapi"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !key.allows(r.URL.Path) {
+				logger.Warn("forbidden request", "method", r.Method, "path", r.URL.Path)
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// lookupAPIKey returns the key matching given. Every key is compared so the
+// time taken does not reveal which one matched.
+func lookupAPIKey(keys []APIKey, given string) (APIKey, bool) {
+	sum := sha256.Sum256([]byte(given))
+
+	var found APIKey
+	ok := false
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare(sum[:], key.digest[:]) == 1 {
+			found, ok = key, true
+		}
+	}
+	return found, ok
+}
+
+// MatchPaths reports whether path matches any of the path patterns accepted
+// by RequireAPIKey.
+func MatchPaths(patterns []string, path string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return matchPath(pattern, path)
+	})
+}
+
+// matchPath reports whether path matches pattern: "*" matches everything, a
+// pattern ending in "/*" matches the prefix and everything below it, and any
+// other pattern must equal path.
+func matchPath(pattern, path string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return path == pattern
+}
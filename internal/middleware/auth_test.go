@@ -0,0 +1,155 @@
+package middleware_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	handler := middleware.RequireBearerToken("s3cret", logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid token", header: "Bearer s3cret", status: http.StatusNoContent},
+		{name: "case insensitive scheme", header: "bearer s3cret", status: http.StatusNoContent},
+		{name: "wrong token", header: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic s3cret", status: http.StatusUnauthorized},
+		{name: "missing header", header: "", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/elements", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.status == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="randomapi"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	keys := make([]middleware.APIKey, 0, 3)
+	for _, entry := range []string{
+		"full",
+		"jokes /jokes/* /datasets",
+		"sha256:5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8", // "password"
+	} {
+		key, err := middleware.ParseAPIKey(entry)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+
+	handler := middleware.RequireAPIKey(keys, []string{"/healthz"}, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "bearer token", path: "/random", headers: map[string]string{"Authorization": "Bearer full"}, status: http.StatusNoContent},
+		{name: "api key header", path: "/random", headers: map[string]string{middleware.APIKeyHeader: "full"}, status: http.StatusNoContent},
+		{name: "hashed key", path: "/random", headers: map[string]string{middleware.APIKeyHeader: "password"}, status: http.StatusNoContent},
+		{name: "scoped prefix", path: "/jokes/random", headers: map[string]string{middleware.APIKeyHeader: "jokes"}, status: http.StatusNoContent},
+		{name: "scoped exact path", path: "/datasets", headers: map[string]string{middleware.APIKeyHeader: "jokes"}, status: http.StatusNoContent},
+		{name: "outside scope", path: "/quotes/random", headers: map[string]string{middleware.APIKeyHeader: "jokes"}, status: http.StatusForbidden},
+		{name: "prefix is not a path prefix", path: "/jokesters/random", headers: map[string]string{middleware.APIKeyHeader: "jokes"}, status: http.StatusForbidden},
+		{name: "unknown key", path: "/random", headers: map[string]string{middleware.APIKeyHeader: "nope"}, status: http.StatusUnauthorized},
+		{name: "missing key", path: "/random", status: http.StatusUnauthorized},
+		{name: "exempt path", path: "/healthz", status: http.StatusNoContent},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.status == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="randomapi"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	t.Parallel()
+
+	for _, entry := range []string{"", "   ", "sha256:abc", "key jokes"} {
+		_, err := middleware.ParseAPIKey(entry)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads entries and skips comments", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "keys")
+		require.NoError(t, os.WriteFile(path, []byte("# widget\nk1 /jokes/*\n\nk2\n"), 0o600))
+
+		keys, err := middleware.LoadAPIKeys(path)
+		require.NoError(t, err)
+		assert.Len(t, keys, 2)
+	})
+
+	t.Run("reports the line of invalid entries", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "keys")
+		require.NoError(t, os.WriteFile(path, []byte("k1\nk2 jokes\n"), 0o600))
+
+		_, err := middleware.LoadAPIKeys(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2:")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := middleware.LoadAPIKeys(filepath.Join(t.TempDir(), "missing"))
+		require.ErrorContains(t, err, "read API keys:")
+	})
+}
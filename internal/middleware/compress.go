@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gi8lino/randomapi/internal/compress"
+)
+
+// Compress returns middleware that compresses response bodies of at least
+// minSize bytes with the encoding negotiated from Accept-Encoding. Responses
+// that already carry a Content-Encoding, such as pre-compressed elements, are
+// passed through unchanged.
+func Compress(minSize int, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			compress.Vary(w.Header())
+
+			enc := compress.Negotiate(r, compress.Encodings)
+			if enc == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, enc: enc, minSize: minSize, status: http.StatusOK}
+			next.ServeHTTP(cw, r)
+			if err := cw.Close(); err != nil {
+				logger.Error("compress response", "encoding", enc, "error", err)
+			}
+		})
+	}
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body reaches the minimum size, then writes it compressed or as is.
+type compressWriter struct {
+	http.ResponseWriter
+	enc         compress.Encoding
+	minSize     int
+	status      int
+	buf         []byte
+	decided     bool
+	wroteHeader bool
+	cw          *compress.Writer // nil while undecided or when not compressing
+}
+
+// WriteHeader records the status code; it is sent once the body is decided.
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+}
+
+// Write buffers b until minSize bytes are known, then streams the body.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Close writes a buffered body that stayed below minSize and finishes the
+// compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.cw != nil {
+		return w.cw.Close()
+	}
+	return nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the header, compressing when large is set and the response is
+// eligible, and flushes the buffered body.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+
+	h := w.Header()
+	if large && w.status == http.StatusOK && h.Get("Content-Encoding") == "" {
+		h.Set("Content-Encoding", string(w.enc))
+		h.Del("Content-Length")
+		compress.Weaken(h)
+
+		cw, err := compress.NewWriter(w.enc, w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.cw = cw
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	large := strings.Repeat("a", 100)
+
+	// respond writes body in two chunks with a strong ETag.
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"abc"`)
+			_, _ = w.Write([]byte(body[:len(body)/2]))
+			_, _ = w.Write([]byte(body[len(body)/2:]))
+		})
+	}
+
+	t.Run("compresses large responses", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		middleware.Compress(64, logger)(respond(large)).ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		assert.Equal(t, `W/"abc"`, rec.Header().Get("ETag"))
+
+		gr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("leaves small responses uncompressed", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		middleware.Compress(64, logger)(respond("small")).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, `"abc"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "small", rec.Body.String())
+	})
+
+	t.Run("leaves responses uncompressed without accepted encoding", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.Compress(64, logger)(respond(large)).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		assert.Equal(t, large, rec.Body.String())
+	})
+
+	t.Run("passes through encoded and non-200 responses", func(t *testing.T) {
+		t.Parallel()
+
+		encoded := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = w.Write([]byte(large))
+		})
+		failed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, large, http.StatusNotFound)
+		})
+
+		for _, h := range []http.Handler{encoded, failed} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+
+			middleware.Compress(64, logger)(h).ServeHTTP(rec, req)
+
+			assert.NotEqual(t, "gzip", rec.Header().Get("Content-Encoding"))
+			assert.Contains(t, rec.Body.String(), large)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	opts := middleware.CORSOptions{
+		AllowedOrigins: []string{"https://example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+		AllowedHeaders: []string{"Authorization", "X-API-Key"},
+		ExposedHeaders: []string{"ETag", "X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	t.Run("simple request from allowed origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/random", nil)
+		req.Header.Set("Origin", "https://example.com")
+		rec := httptest.NewRecorder()
+
+		middleware.CORS(opts)(next).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, []string{"Origin"}, rec.Header().Values("Vary"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "ETag, X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("wildcard origin pattern", func(t *testing.T) {
+		t.Parallel()
+
+		handler := middleware.CORS(opts)(next)
+		for origin, allowed := range map[string]bool{
+			"https://app.example.org":     true,
+			"https://a.b.example.org":     true,
+			"https://example.org":         false,
+			"https://app.example.org.com": false,
+			"http://app.example.org":      false,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/random", nil)
+			req.Header.Set("Origin", origin)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code, origin)
+			assert.Equal(t, allowed, rec.Header().Get("Access-Control-Allow-Origin") == origin, origin)
+		}
+	})
+
+	t.Run("request without origin", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		middleware.CORS(opts)(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/random", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodOptions, "/random", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "x-api-key")
+		rec := httptest.NewRecorder()
+
+		middleware.CORS(opts)(next).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, HEAD", rec.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "x-api-key", rec.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rec.Header().Values("Vary"))
+	})
+
+	t.Run("preflight with disallowed method or header", func(t *testing.T) {
+		t.Parallel()
+
+		handler := middleware.CORS(opts)(next)
+		for _, tc := range []struct{ method, headers string }{
+			{method: http.MethodDelete},
+			{method: http.MethodGet, headers: "X-Custom"},
+		} {
+			req := httptest.NewRequest(http.MethodOptions, "/random", nil)
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", tc.method)
+			req.Header.Set("Access-Control-Request-Headers", tc.headers)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+		}
+	})
+
+	t.Run("preflight from disallowed origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodOptions, "/random", nil)
+		req.Header.Set("Origin", "https://evil.test")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+
+		middleware.CORS(opts)(next).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("any origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/random", nil)
+		req.Header.Set("Origin", "https://anywhere.test")
+		rec := httptest.NewRecorder()
+
+		middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"*"}})(next).ServeHTTP(rec, req)
+
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("credentials echo the origin", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodOptions, "/random", nil)
+		req.Header.Set("Origin", "https://app.example.org")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+		rec := httptest.NewRecorder()
+
+		middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   []string{"https://*.example.org"},
+			AllowedMethods:   []string{http.MethodGet},
+			AllowedHeaders:   []string{"*"},
+			AllowCredentials: true,
+		})(next).ServeHTTP(rec, req)
+
+		assert.Equal(t, "https://app.example.org", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Custom", rec.Header().Get("Access-Control-Allow-Headers"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Max-Age"))
+	})
+}
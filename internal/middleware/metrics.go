@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gi8lino/randomapi/internal/metrics"
+)
+
+// unmatchedRoute labels requests that did not match any registered pattern.
+const unmatchedRoute = "unmatched"
+
+// Metrics returns middleware recording request count and latency per route
+// pattern and status code. It must wrap the http.ServeMux directly so the
+// matched pattern is available once the request was served.
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := newStatusWriter(w)
+
+			next.ServeHTTP(sw, r)
+
+			route := r.Pattern
+			if route == "" {
+				route = unmatchedRoute
+			}
+			m.ObserveRequest(route, sw.status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/metrics"
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := metrics.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /index/{nr}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := middleware.Metrics(m)(mux)
+
+	for _, path := range []string{"/index/1", "/index/2", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `randomapi_http_requests_total{code="418",route="GET /index/{nr}"} 2`)
+	assert.Contains(t, rec.Body.String(), `randomapi_http_requests_total{code="404",route="unmatched"} 1`)
+}
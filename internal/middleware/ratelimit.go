@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// APIKeyHeader carries an API key identifying the client.
+const APIKeyHeader = "X-API-Key"
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	Rate           float64        // Sustained requests per second per client
+	Burst          int            // Requests a client may make at once
+	KeyByAPIKey    bool           // Key clients by their API key or bearer token when it is one of APIKeys
+	APIKeys        []APIKey       // Known keys; requests carrying any other key are keyed by IP
+	TrustedProxies []netip.Prefix // Proxies whose X-Forwarded-For header is trusted
+	MaxClients     int            // Client buckets kept in memory
+}
+
+// RateLimiter keeps a token bucket for each of the most recently seen clients.
+type RateLimiter struct {
+	opts    RateLimitOptions
+	buckets *lru.Cache[string, *bucket]
+	logger  *slog.Logger
+}
+
+// NewRateLimiter returns a RateLimiter limiting every client to a token
+// bucket of opts.Burst requests refilled at opts.Rate per second.
+func NewRateLimiter(opts RateLimitOptions, logger *slog.Logger) (*RateLimiter, error) {
+	buckets, err := lru.New[string, *bucket](opts.MaxClients)
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimiter{opts: opts, buckets: buckets, logger: logger}, nil
+}
+
+// Middleware returns middleware enforcing the limits. Clients are keyed by
+// IP, taken from X-Forwarded-For only when the peer is a trusted proxy, or by
+// known API key. Responses carry RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset; rejected requests get 429 with Retry-After. Requests to
+// skipPaths are not limited.
+func (l *RateLimiter) Middleware(skipPaths ...string) func(http.Handler) http.Handler {
+	opts := l.opts
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(skipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			b := l.bucket(opts.clientKey(r))
+			allowed, remaining, retryAfter, reset := b.take(time.Now(), opts.Rate, float64(opts.Burst))
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(opts.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+
+			if !allowed {
+				l.logger.Debug("rate limit exceeded", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bucket returns the bucket of key, creating a full one if needed.
+func (l *RateLimiter) bucket(key string) *bucket {
+	if b, ok := l.buckets.Get(key); ok {
+		return b
+	}
+	b := &bucket{tokens: float64(l.opts.Burst), last: time.Now()}
+	if prev, ok, _ := l.buckets.PeekOrAdd(key, b); ok {
+		return prev
+	}
+	return b
+}
+
+// clientKey returns the bucket key for the client sending r.
+func (o RateLimitOptions) clientKey(r *http.Request) string {
+	if o.KeyByAPIKey {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			key, _ = bearerToken(r)
+		}
+		// Unknown keys are keyed by IP, so rotating them cannot get a
+		// fresh bucket. Only a digest is kept so keys never linger in memory.
+		if known, ok := lookupAPIKey(o.APIKeys, key); ok {
+			return "key:" + hex.EncodeToString(known.digest[:])
+		}
+	}
+	return "ip:" + o.clientIP(r)
+}
+
+// clientIP returns the address of the client sending r. X-Forwarded-For is
+// walked from the nearest hop and only trusted while hops are trusted proxies.
+func (o RateLimitOptions) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !o.trusted(addr) {
+		return host
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	client := addr
+	for _, hop := range slices.Backward(hops) {
+		hopAddr, err := netip.ParseAddr(hop)
+		if err != nil {
+			break
+		}
+		client = hopAddr.Unmap()
+		if !o.trusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// trusted reports whether addr belongs to a trusted proxy.
+func (o RateLimitOptions) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range o.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucket is the token bucket of one client.
+type bucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket up to now and takes one token if available. It
+// returns the whole tokens left, the wait until the next token and the wait
+// until the bucket is full again.
+func (b *bucket) take(now time.Time, rate, burst float64) (allowed bool, remaining int, retryAfter, reset time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	} else {
+		retryAfter = secondsDuration((1 - b.tokens) / rate)
+	}
+	return allowed, int(b.tokens), retryAfter, secondsDuration((burst - b.tokens) / rate)
+}
+
+// secondsDuration converts fractional seconds to a duration.
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,132 @@
+package middleware_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	// newLimited returns ok limited to 2 requests at once and 1 per second.
+	newLimited := func(t *testing.T, opts middleware.RateLimitOptions) http.Handler {
+		t.Helper()
+
+		opts.Rate, opts.Burst, opts.MaxClients = 1, 2, 100
+		limiter, err := middleware.NewRateLimiter(opts, logger)
+		require.NoError(t, err)
+		return limiter.Middleware("/healthz")(ok)
+	}
+
+	// serve sends a request from remote with the given headers.
+	serve := func(h http.Handler, path, remote string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("rejects clients exceeding the burst", func(t *testing.T) {
+		t.Parallel()
+
+		h := newLimited(t, middleware.RateLimitOptions{})
+
+		rec := serve(h, "/random", "10.0.0.1:1000", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Reset"))
+
+		rec = serve(h, "/random", "10.0.0.1:1001", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+
+		rec = serve(h, "/random", "10.0.0.1:1002", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+		// Other clients and skipped paths are unaffected.
+		assert.Equal(t, http.StatusOK, serve(h, "/random", "10.0.0.2:1000", nil).Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/healthz", "10.0.0.1:1003", nil).Code)
+	})
+
+	t.Run("trusts X-Forwarded-For only from proxies", func(t *testing.T) {
+		t.Parallel()
+
+		h := newLimited(t, middleware.RateLimitOptions{
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		})
+
+		// Two clients behind the proxy get separate buckets.
+		for range 2 {
+			assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "203.0.113.1"}).Code)
+		}
+		assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "203.0.113.2, 10.0.0.5"}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "/", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "198.51.100.9, 203.0.113.1"}).Code)
+
+		// A spoofed header from an untrusted peer is ignored.
+		for range 2 {
+			assert.Equal(t, http.StatusOK, serve(h, "/", "192.0.2.1:1000", map[string]string{"X-Forwarded-For": "203.0.113.3"}).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "/", "192.0.2.1:1000", map[string]string{"X-Forwarded-For": "203.0.113.4"}).Code)
+	})
+
+	t.Run("keys clients by API key", func(t *testing.T) {
+		t.Parallel()
+
+		alpha, err := middleware.ParseAPIKey("alpha")
+		require.NoError(t, err)
+		beta, err := middleware.ParseAPIKey("beta")
+		require.NoError(t, err)
+		h := newLimited(t, middleware.RateLimitOptions{KeyByAPIKey: true, APIKeys: []middleware.APIKey{alpha, beta}})
+
+		for range 2 {
+			assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{middleware.APIKeyHeader: "alpha"}).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "/", "10.0.0.2:1000", map[string]string{middleware.APIKeyHeader: "alpha"}).Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{"Authorization": "Bearer beta"}).Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", nil).Code)
+	})
+
+	t.Run("keys unknown API keys by IP", func(t *testing.T) {
+		t.Parallel()
+
+		alpha, err := middleware.ParseAPIKey("alpha")
+		require.NoError(t, err)
+		h := newLimited(t, middleware.RateLimitOptions{KeyByAPIKey: true, APIKeys: []middleware.APIKey{alpha}})
+
+		// Rotating keys does not earn a fresh bucket.
+		for _, key := range []string{"junk-1", "junk-2"} {
+			assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{middleware.APIKeyHeader: key}).Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "/", "10.0.0.1:1000", map[string]string{middleware.APIKeyHeader: "junk-3"}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(h, "/", "10.0.0.1:1000", map[string]string{"Authorization": "Bearer junk-4"}).Code)
+		assert.Equal(t, http.StatusOK, serve(h, "/", "10.0.0.1:1000", map[string]string{middleware.APIKeyHeader: "alpha"}).Code)
+	})
+
+	t.Run("rejects invalid client limit", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := middleware.NewRateLimiter(middleware.RateLimitOptions{}, logger)
+		require.Error(t, err)
+	})
+}
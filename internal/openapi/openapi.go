@@ -0,0 +1,476 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/gi8lino/randomapi/internal/render"
+)
+
+// Path is the path the document is served at.
+const Path = "/openapi.json"
+
+// Options describes the API served by the router.
+type Options struct {
+	Version     string         // API version reported in info.version
+	Prefix      string         // Route prefix all paths are served under ("" = root)
+	Store       *data.Store    // Dataset served at the root (nil = none)
+	Datasets    []data.Dataset // Named datasets served below /{name}
+	MaxCount    int            // Maximum value of the "count" query parameter
+	InferSchema bool           // Describe elements with a schema inferred from the loaded data
+	Metrics     bool           // /metrics is served on this router
+	Admin       bool           // The /admin API is enabled
+	APIKeys     bool           // Requests require an API key
+	AuthExempt  []string       // Path patterns served without an API key
+	RateLimit   bool           // Requests may be rejected with 429
+}
+
+// object is a JSON object in the document.
+type object = map[string]any
+
+// Handler returns a handler that responds with the OpenAPI 3.1 document for
+// opts. The document is built per request so inferred schemas follow reloads.
+func Handler(opts Options, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(Document(opts)); err != nil {
+			logger.Error("write response", "error", err)
+		}
+	}
+}
+
+// Document returns the OpenAPI 3.1 document describing every route of the
+// API configured by opts.
+func Document(opts Options) object {
+	paths := object{}
+	schemas := object{
+		"DatasetInfo": object{
+			"type":     "object",
+			"required": []string{"name", "count"},
+			"properties": object{
+				"name":  object{"type": "string"},
+				"count": object{"type": "integer"},
+			},
+		},
+		"Readiness": readinessSchema(),
+		"EditResult": object{
+			"type":     "object",
+			"required": []string{"index", "count"},
+			"properties": object{
+				"index": object{"type": "integer", "description": "Index of the edited element"},
+				"count": object{"type": "integer", "description": "Number of elements after the edit"},
+			},
+		},
+	}
+
+	paths["/healthz"] = object{
+		"get":  healthzOperation("getHealthz"),
+		"post": healthzOperation("postHealthz"),
+	}
+	paths["/readyz"] = object{"get": object{
+		"operationId": "getReadyz",
+		"summary":     "Report readiness and dataset state",
+		"tags":        []string{"health"},
+		"responses": object{
+			"200": jsonResponse("Ready", ref("Readiness")),
+			"503": jsonResponse("Not ready", ref("Readiness")),
+		},
+	}}
+	if opts.Metrics {
+		paths["/metrics"] = object{"get": object{
+			"operationId": "getMetrics",
+			"summary":     "Prometheus metrics",
+			"tags":        []string{"health"},
+			"responses": object{
+				"200": object{
+					"description": "Metrics in the Prometheus text format",
+					"content":     object{"text/plain": object{"schema": object{"type": "string"}}},
+				},
+			},
+		}}
+	}
+	paths[Path] = object{"get": object{
+		"operationId": "getOpenAPI",
+		"summary":     "This OpenAPI document",
+		"tags":        []string{"meta"},
+		"responses": object{
+			"200": jsonResponse("OpenAPI 3.1 document", object{"type": "object"}),
+		},
+	}}
+	paths["/datasets"] = object{"get": object{
+		"operationId": "listDatasets",
+		"summary":     "List the named datasets with their element counts",
+		"tags":        []string{"meta"},
+		"responses": object{
+			"200": jsonResponse("Named datasets", object{"type": "array", "items": ref("DatasetInfo")}),
+		},
+	}}
+
+	if opts.Store != nil {
+		schemas["Element"] = elementSchema(opts.Store, opts.InferSchema, "the root dataset")
+		addDataset(paths, opts, "", "default", "Element")
+	}
+	for _, ds := range opts.Datasets {
+		name := "Element." + ds.Name
+		schemas[name] = elementSchema(ds.Store, opts.InferSchema, fmt.Sprintf("the %q dataset", ds.Name))
+		addDataset(paths, opts, "/"+ds.Name, ds.Name, name)
+	}
+
+	components := object{
+		"schemas":    schemas,
+		"parameters": parameters(opts.MaxCount),
+		"responses":  errorResponses(),
+	}
+
+	doc := object{
+		"openapi": "3.1.0",
+		"info": object{
+			"title":       "randomAPI",
+			"version":     opts.Version,
+			"description": "Serves random, indexed and periodic elements from JSON datasets.",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+	if opts.Prefix != "" {
+		doc["servers"] = []object{{"url": opts.Prefix}}
+	}
+
+	securitySchemes := object{}
+	if opts.APIKeys {
+		securitySchemes["bearerAuth"] = object{"type": "http", "scheme": "bearer"}
+		securitySchemes["apiKey"] = object{"type": "apiKey", "in": "header", "name": middleware.APIKeyHeader}
+		doc["security"] = []object{{"bearerAuth": []string{}}, {"apiKey": []string{}}}
+	}
+	if opts.Admin {
+		securitySchemes["adminToken"] = object{"type": "http", "scheme": "bearer"}
+	}
+	if len(securitySchemes) > 0 {
+		components["securitySchemes"] = securitySchemes
+	}
+
+	for path, item := range paths {
+		for _, op := range item.(object) {
+			decorate(op.(object), path, opts)
+		}
+	}
+	return doc
+}
+
+// addDataset adds the element endpoints of one dataset below base.
+func addDataset(paths object, opts Options, base, tag, schemaName string) {
+	element := ref(schemaName)
+
+	paths[base+"/random"] = object{"get": object{
+		"operationId": "random_" + tag,
+		"summary":     "Pick a random element",
+		"description": "Picks an element honouring configured weights. With count, responds with a list.",
+		"tags":        []string{tag},
+		"parameters": []object{
+			paramRef("format"), paramRef("seed"), paramRef("filter"), paramRef("count"), paramRef("unique"),
+		},
+		"responses": object{
+			"200": elementResponse("A random element, or a list of elements with count", object{
+				"oneOf": []object{element, {"type": "array", "items": element}},
+			}),
+			"400": responseRef("BadRequest"),
+			"404": responseRef("NoMatch"),
+			"500": responseRef("InternalError"),
+		},
+	}}
+
+	paths[base+"/index/{nr}"] = object{"get": object{
+		"operationId": "index_" + tag,
+		"summary":     "Get the element at an index",
+		"tags":        []string{tag},
+		"parameters": []object{
+			{"name": "nr", "in": "path", "required": true, "description": "Zero-based element index", "schema": object{"type": "integer", "minimum": 0}},
+			paramRef("format"),
+			{"name": "If-None-Match", "in": "header", "description": "Entity tags of cached representations", "schema": object{"type": "string"}},
+		},
+		"responses": object{
+			"200": withHeaders(elementResponse("The element", element), "ETag", "Cache-Control"),
+			"304": object{"description": "The cached representation is still current"},
+			"400": responseRef("BadRequest"),
+			"500": responseRef("InternalError"),
+		},
+	}}
+
+	periodResponses := func() object {
+		return object{
+			"200": withHeaders(elementResponse("The element of the period", element), "Cache-Control", "Expires"),
+			"400": responseRef("BadRequest"),
+			"500": responseRef("InternalError"),
+		}
+	}
+	paths[base+"/daily"] = object{"get": object{
+		"operationId": "daily_" + tag,
+		"summary":     "Get the element of the day",
+		"tags":        []string{tag},
+		"parameters": []object{
+			{"name": "date", "in": "query", "description": "Day to pick for instead of today", "schema": object{"type": "string", "format": "date"}},
+			paramRef("format"),
+		},
+		"responses": periodResponses(),
+	}}
+	paths[base+"/hourly"] = object{"get": object{
+		"operationId": "hourly_" + tag,
+		"summary":     "Get the element of the hour",
+		"tags":        []string{tag},
+		"parameters":  []object{paramRef("format")},
+		"responses":   periodResponses(),
+	}}
+	paths[base+"/period/{duration}"] = object{"get": object{
+		"operationId": "period_" + tag,
+		"summary":     "Get the element of the current period",
+		"tags":        []string{tag},
+		"parameters": []object{
+			{"name": "duration", "in": "path", "required": true, "description": "Period length of at least 1s, e.g. 15m or 6h", "schema": object{"type": "string"}},
+			paramRef("format"),
+		},
+		"responses": periodResponses(),
+	}}
+
+	if !opts.Admin {
+		return
+	}
+	adminBase := "/admin" + base
+	indexParam := object{"name": "nr", "in": "path", "required": true, "schema": object{"type": "integer", "minimum": 0}}
+	body := object{"required": true, "content": object{"application/json": object{"schema": element}}}
+	editErrors := func(ok string, result object) object {
+		return object{
+			ok:    result,
+			"400": responseRef("BadRequest"),
+			"404": responseRef("NotFound"),
+			"409": responseRef("Conflict"),
+			"413": responseRef("TooLarge"),
+			"500": responseRef("InternalError"),
+		}
+	}
+	paths[adminBase+"/elements"] = object{"post": object{
+		"operationId": "addElement_" + tag,
+		"summary":     "Append an element",
+		"tags":        []string{"admin"},
+		"requestBody": body,
+		"responses":   editErrors("201", jsonResponse("The element was added", ref("EditResult"))),
+	}}
+	paths[adminBase+"/elements/{nr}"] = object{
+		"put": object{
+			"operationId": "updateElement_" + tag,
+			"summary":     "Replace the element at an index",
+			"tags":        []string{"admin"},
+			"parameters":  []object{indexParam},
+			"requestBody": body,
+			"responses":   editErrors("200", jsonResponse("The element was replaced", ref("EditResult"))),
+		},
+		"delete": object{
+			"operationId": "deleteElement_" + tag,
+			"summary":     "Delete the element at an index",
+			"tags":        []string{"admin"},
+			"parameters":  []object{indexParam},
+			"responses":   editErrors("204", object{"description": "The element was deleted"}),
+		},
+	}
+}
+
+// decorate adds the security requirements and responses that middleware
+// applies to the operation at path.
+func decorate(op object, path string, opts Options) {
+	responses := op["responses"].(object)
+	admin := opts.Admin && isAdminPath(path)
+
+	switch {
+	case admin:
+		op["security"] = []object{{"adminToken": []string{}}}
+		responses["401"] = responseRef("Unauthorized")
+	case opts.APIKeys && middleware.MatchPaths(opts.AuthExempt, path):
+		op["security"] = []object{}
+	case opts.APIKeys:
+		responses["401"] = responseRef("Unauthorized")
+		responses["403"] = responseRef("Forbidden")
+	}
+
+	if opts.RateLimit && path != "/healthz" && path != "/readyz" {
+		responses["429"] = responseRef("TooManyRequests")
+	}
+}
+
+// isAdminPath reports whether path is one of the admin API paths, which a
+// dataset named like an admin path segment cannot be mistaken for.
+func isAdminPath(path string) bool {
+	return strings.HasPrefix(path, "/admin/") &&
+		(strings.HasSuffix(path, "/elements") || strings.HasSuffix(path, "/elements/{nr}"))
+}
+
+// elementSchema returns the schema of the elements in store.
+func elementSchema(store *data.Store, infer bool, dataset string) object {
+	schema := object{}
+	if infer {
+		schema = InferSchema(store.Elements())
+	}
+	schema["description"] = "An element of " + dataset
+	return schema
+}
+
+// parameters returns the shared query parameters.
+func parameters(maxCount int) object {
+	formats := make([]string, 0, len(render.Formats))
+	for _, f := range render.Formats {
+		formats = append(formats, string(f))
+	}
+
+	return object{
+		"format": object{
+			"name":        "format",
+			"in":          "query",
+			"description": "Response format; takes precedence over the Accept header",
+			"schema":      object{"type": "string", "enum": formats},
+		},
+		"seed": object{
+			"name":        "seed",
+			"in":          "query",
+			"description": "Makes the pick deterministic",
+			"schema":      object{"type": "string"},
+		},
+		"filter": object{
+			"name":        "filter",
+			"in":          "query",
+			"description": "Restricts the pick to object elements whose FIELD equals VALUE, given as FIELD:VALUE (repeatable, ANDed)",
+			"schema":      object{"type": "array", "items": object{"type": "string"}},
+			"style":       "form",
+			"explode":     true,
+			"example":     []string{"type:programming"},
+		},
+		"count": object{
+			"name":        "count",
+			"in":          "query",
+			"description": "Responds with a list of this many elements",
+			"schema":      object{"type": "integer", "minimum": 1, "maximum": maxCount},
+		},
+		"unique": object{
+			"name":        "unique",
+			"in":          "query",
+			"description": "Never repeat an element within one list",
+			"schema":      object{"type": "boolean"},
+		},
+	}
+}
+
+// errorResponses returns the shared plain-text error responses.
+func errorResponses() object {
+	plain := func(description string) object {
+		return object{
+			"description": description,
+			"content":     object{"text/plain": object{"schema": object{"type": "string"}}},
+		}
+	}
+
+	return object{
+		"BadRequest":    plain("Invalid parameter"),
+		"NotFound":      plain("No element at the index"),
+		"NoMatch":       plain("No element matches the filter"),
+		"Conflict":      plain("The edit conflicts with the dataset"),
+		"TooLarge":      plain("The element is too large"),
+		"InternalError": plain("No elements are available or the response could not be rendered"),
+		"Unauthorized":  withHeaders(plain("Missing or invalid credentials"), "WWW-Authenticate"),
+		"Forbidden":     plain("The API key may not access this path"),
+		"TooManyRequests": withHeaders(
+			plain("Rate limit exceeded"),
+			"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+		),
+	}
+}
+
+// readinessSchema returns the schema of the /readyz body.
+func readinessSchema() object {
+	return object{
+		"type":     "object",
+		"required": []string{"status", "version", "datasets"},
+		"properties": object{
+			"status":  object{"type": "string", "enum": []string{"ready", "not ready"}},
+			"reason":  object{"type": "string"},
+			"version": object{"type": "string"},
+			"datasets": object{"type": "array", "items": object{
+				"type": "object",
+				"properties": object{
+					"name":                        object{"type": "string"},
+					"source":                      object{"type": "string"},
+					"count":                       object{"type": "integer"},
+					"loaded_at":                   object{"type": "string", "format": "date-time"},
+					"reload_failures":             object{"type": "integer"},
+					"consecutive_reload_failures": object{"type": "integer"},
+				},
+			}},
+		},
+	}
+}
+
+// healthzOperation returns a /healthz operation.
+func healthzOperation(id string) object {
+	return object{
+		"operationId": id,
+		"summary":     "Liveness probe",
+		"tags":        []string{"health"},
+		"responses": object{
+			"200": object{
+				"description": "The server is alive",
+				"content":     object{"text/plain": object{"schema": object{"type": "string", "const": "ok"}}},
+			},
+		},
+	}
+}
+
+// elementResponse returns a response rendering schema as JSON or as any of
+// the other formats.
+func elementResponse(description string, schema object) object {
+	content := object{}
+	for _, f := range render.Formats {
+		mediaType := object{"schema": object{"type": "string"}}
+		if f == render.FormatJSON {
+			mediaType = object{"schema": schema}
+		}
+		mediaRange, _, _ := strings.Cut(f.ContentType(), ";")
+		content[mediaRange] = mediaType
+	}
+	return object{"description": description, "content": content}
+}
+
+// jsonResponse returns a response with a JSON body matching schema.
+func jsonResponse(description string, schema object) object {
+	return object{
+		"description": description,
+		"content":     object{"application/json": object{"schema": schema}},
+	}
+}
+
+// withHeaders documents string headers on response.
+func withHeaders(response object, names ...string) object {
+	headers := object{}
+	for _, name := range names {
+		headers[name] = object{"schema": object{"type": "string"}}
+	}
+	response["headers"] = headers
+	return response
+}
+
+// ref returns a reference to a component schema.
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// paramRef returns a reference to a component parameter.
+func paramRef(name string) object {
+	return object{"$ref": "#/components/parameters/" + name}
+}
+
+// responseRef returns a reference to a component response.
+func responseRef(name string) object {
+	return object{"$ref": "#/components/responses/" + name}
+}
@@ -0,0 +1,146 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	t.Parallel()
+
+	store, err := data.NewStore(data.Elements{[]byte(`{"text":"a"}`)}, data.Options{})
+	require.NoError(t, err)
+	jokes, err := data.NewStore(data.Elements{[]byte(`"joke"`)}, data.Options{})
+	require.NoError(t, err)
+	datasets := []data.Dataset{{Name: "jokes", Store: jokes}}
+
+	t.Run("paths and schemas", func(t *testing.T) {
+		t.Parallel()
+
+		doc := decode(t, openapi.Document(openapi.Options{
+			Version:     "v1.2.3",
+			Prefix:      "/api",
+			Store:       store,
+			Datasets:    datasets,
+			MaxCount:    10,
+			InferSchema: true,
+		}))
+
+		assert.Equal(t, "3.1.0", doc["openapi"])
+		assert.Equal(t, "v1.2.3", doc["info"].(map[string]any)["version"])
+		assert.Equal(t, []any{map[string]any{"url": "/api"}}, doc["servers"])
+
+		var paths []string
+		for path := range doc["paths"].(map[string]any) {
+			paths = append(paths, path)
+		}
+		assert.ElementsMatch(t, []string{
+			"/healthz", "/readyz", "/openapi.json", "/datasets",
+			"/random", "/index/{nr}", "/daily", "/hourly", "/period/{duration}",
+			"/jokes/random", "/jokes/index/{nr}", "/jokes/daily", "/jokes/hourly", "/jokes/period/{duration}",
+		}, paths)
+
+		schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+		assert.Equal(t, "object", schemas["Element"].(map[string]any)["type"])
+		assert.Equal(t, "string", schemas["Element.jokes"].(map[string]any)["type"])
+
+		count := doc["components"].(map[string]any)["parameters"].(map[string]any)["count"].(map[string]any)
+		assert.Equal(t, 10.0, count["schema"].(map[string]any)["maximum"])
+		filter := doc["components"].(map[string]any)["parameters"].(map[string]any)["filter"].(map[string]any)
+		assert.Equal(t, []any{"type:programming"}, filter["example"])
+
+		random := doc["paths"].(map[string]any)["/random"].(map[string]any)["get"].(map[string]any)
+		assert.Contains(t, random["responses"], "400")
+		assert.NotContains(t, random["responses"], "401")
+		assert.NotContains(t, doc, "security")
+	})
+
+	t.Run("without inferred schema", func(t *testing.T) {
+		t.Parallel()
+
+		doc := decode(t, openapi.Document(openapi.Options{Store: store}))
+
+		assert.NotContains(t, doc, "servers")
+		element := doc["components"].(map[string]any)["schemas"].(map[string]any)["Element"].(map[string]any)
+		assert.NotContains(t, element, "type")
+	})
+
+	t.Run("security and rate limits", func(t *testing.T) {
+		t.Parallel()
+
+		doc := decode(t, openapi.Document(openapi.Options{
+			Store:      store,
+			Datasets:   []data.Dataset{{Name: "admin", Store: store}},
+			Metrics:    true,
+			Admin:      true,
+			APIKeys:    true,
+			AuthExempt: []string{"/healthz"},
+			RateLimit:  true,
+		}))
+		paths := doc["paths"].(map[string]any)
+		operation := func(path, method string) map[string]any {
+			return paths[path].(map[string]any)[method].(map[string]any)
+		}
+
+		assert.Len(t, doc["security"], 2)
+		assert.Contains(t, paths, "/metrics")
+
+		random := operation("/random", "get")
+		assert.NotContains(t, random, "security")
+		assert.Contains(t, random["responses"], "401")
+		assert.Contains(t, random["responses"], "403")
+		assert.Contains(t, random["responses"], "429")
+
+		healthz := operation("/healthz", "get")
+		assert.Equal(t, []any{}, healthz["security"])
+		assert.NotContains(t, healthz["responses"], "429")
+
+		add := operation("/admin/elements", "post")
+		assert.Equal(t, []any{map[string]any{"adminToken": []any{}}}, add["security"])
+		assert.Contains(t, add["responses"], "201")
+		assert.Contains(t, paths["/admin/elements/{nr}"], "delete")
+
+		// A dataset named like the admin API is still guarded by API keys.
+		adminRandom := operation("/admin/random", "get")
+		assert.NotContains(t, adminRandom, "security")
+		assert.Contains(t, adminRandom["responses"], "403")
+		assert.Contains(t, operation("/admin/admin/elements", "post"), "security")
+	})
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	store, err := data.NewStore(data.Elements{[]byte(`1`)}, data.Options{})
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	rec := httptest.NewRecorder()
+	openapi.Handler(openapi.Options{Store: store}, logger).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, openapi.Path, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+}
+
+// decode round-trips doc through JSON, as clients see it.
+func decode(t *testing.T, doc map[string]any) map[string]any {
+	t.Helper()
+
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	return decoded
+}
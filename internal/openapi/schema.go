@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// typeOrder lists JSON Schema types in the order they are reported.
+var typeOrder = []string{"object", "array", "string", "integer", "number", "boolean", "null"}
+
+// shape accumulates the structure of the JSON values it has seen.
+type shape struct {
+	types   map[string]bool
+	objects int               // Number of objects seen
+	props   map[string]*shape // Object properties by name
+	counts  map[string]int    // Number of objects each property appeared in
+	items   *shape            // Merged shape of all array items
+}
+
+// InferSchema returns a JSON Schema describing every element: the union of
+// their types, object properties (required when present in every object) and
+// array items. Elements that are not valid JSON are ignored.
+func InferSchema(elements data.Elements) map[string]any {
+	s := &shape{}
+	for _, elem := range elements {
+		dec := json.NewDecoder(bytes.NewReader(elem))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		s.add(v)
+	}
+	return s.schema()
+}
+
+// add merges v into s.
+func (s *shape) add(v any) {
+	if s.types == nil {
+		s.types = make(map[string]bool)
+	}
+
+	switch v := v.(type) {
+	case nil:
+		s.types["null"] = true
+	case bool:
+		s.types["boolean"] = true
+	case string:
+		s.types["string"] = true
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			s.types["number"] = true
+		} else {
+			s.types["integer"] = true
+		}
+	case []any:
+		s.types["array"] = true
+		for _, item := range v {
+			if s.items == nil {
+				s.items = &shape{}
+			}
+			s.items.add(item)
+		}
+	case map[string]any:
+		s.types["object"] = true
+		s.objects++
+		if s.props == nil {
+			s.props = make(map[string]*shape)
+			s.counts = make(map[string]int)
+		}
+		for name, value := range v {
+			prop, ok := s.props[name]
+			if !ok {
+				prop = &shape{}
+				s.props[name] = prop
+			}
+			prop.add(value)
+			s.counts[name]++
+		}
+	}
+}
+
+// schema returns the JSON Schema for s; an empty shape allows any value.
+func (s *shape) schema() map[string]any {
+	schema := map[string]any{}
+	if s == nil || len(s.types) == 0 {
+		return schema
+	}
+
+	// Every integer is a number, so report only the wider type.
+	if s.types["number"] {
+		delete(s.types, "integer")
+	}
+	var types []string
+	for _, t := range typeOrder {
+		if s.types[t] {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 1 {
+		schema["type"] = types[0]
+	} else {
+		schema["type"] = types
+	}
+
+	if s.types["object"] {
+		props := make(map[string]any, len(s.props))
+		var required []string
+		for name, prop := range s.props {
+			props[name] = prop.schema()
+			if s.counts[name] == s.objects {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = props
+		if len(required) > 0 {
+			slices.Sort(required)
+			schema["required"] = required
+		}
+	}
+	if s.items != nil {
+		schema["items"] = s.items.schema()
+	}
+	return schema
+}
@@ -0,0 +1,47 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferSchema(t *testing.T) {
+	t.Parallel()
+
+	t.Run("objects", func(t *testing.T) {
+		t.Parallel()
+
+		schema := openapi.InferSchema(data.Elements{
+			[]byte(`{"text":"a","score":1,"tags":["x"]}`),
+			[]byte(`{"text":"b","score":1.5,"author":null}`),
+		})
+
+		assert.Equal(t, map[string]any{
+			"type":     "object",
+			"required": []string{"score", "text"},
+			"properties": map[string]any{
+				"text":   map[string]any{"type": "string"},
+				"score":  map[string]any{"type": "number"},
+				"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"author": map[string]any{"type": "null"},
+			},
+		}, schema)
+	})
+
+	t.Run("mixed types", func(t *testing.T) {
+		t.Parallel()
+
+		schema := openapi.InferSchema(data.Elements{[]byte(`"a"`), []byte(`2`), []byte(`true`), []byte(`[]`)})
+
+		assert.Equal(t, map[string]any{"type": []string{"array", "string", "integer", "boolean"}}, schema)
+	})
+
+	t.Run("no elements", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, openapi.InferSchema(nil))
+	})
+}
@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// htmlElements renders elements as an HTML snippet. Each element becomes a
+// div holding the output of the HTML template, if any; with list set, the
+// divs are wrapped in a container.
+func (r *Renderer) htmlElements(elems []data.Element, list bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if list {
+		buf.WriteString(`<div class="randomapi-elements">`)
+	}
+	for _, elem := range elems {
+		buf.WriteString(`<div class="randomapi-element">`)
+		if r != nil && r.html != nil {
+			out, err := executeTemplate(r.html, elem)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(out)
+		} else {
+			v, err := parseValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			writeHTMLValue(&buf, v)
+		}
+		buf.WriteString(`</div>`)
+	}
+	if list {
+		buf.WriteString(`</div>`)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// writeHTMLValue renders objects as definition lists, arrays as lists and
+// scalars as escaped text.
+func writeHTMLValue(buf *bytes.Buffer, v value) {
+	switch v.kind {
+	case kindObject:
+		buf.WriteString("<dl>")
+		for i, key := range v.keys {
+			buf.WriteString("<dt>")
+			buf.WriteString(html.EscapeString(key))
+			buf.WriteString("</dt><dd>")
+			writeHTMLValue(buf, v.items[i])
+			buf.WriteString("</dd>")
+		}
+		buf.WriteString("</dl>")
+	case kindArray:
+		buf.WriteString("<ul>")
+		for _, item := range v.items {
+			buf.WriteString("<li>")
+			writeHTMLValue(buf, item)
+			buf.WriteString("</li>")
+		}
+		buf.WriteString("</ul>")
+	case kindNull:
+	default:
+		buf.WriteString(html.EscapeString(v.text))
+	}
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// Format is a representation elements can be rendered in.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+	FormatHTML Format = "html"
+	FormatXML  Format = "xml"
+)
+
+// Formats lists the supported formats in order of preference for wildcards.
+var Formats = []Format{FormatJSON, FormatText, FormatHTML, FormatXML}
+
+// mediaTypes maps media types accepted in the Accept header to formats.
+var mediaTypes = map[string]Format{
+	"application/json": FormatJSON,
+	"text/plain":       FormatText,
+	"text/html":        FormatHTML,
+	"application/xml":  FormatXML,
+	"text/xml":         FormatXML,
+}
+
+// ContentType returns the Content-Type header value for f.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatText:
+		return "text/plain; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatXML:
+		return "application/xml; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Negotiate picks the format for r. A "format" query parameter takes
+// precedence over the Accept header; JSON is used when neither selects a
+// supported format. Only an unknown "format" value is an error.
+func Negotiate(r *http.Request) (Format, error) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		f := Format(strings.ToLower(raw))
+		for _, known := range Formats {
+			if f == known {
+				return f, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported format %q", raw)
+	}
+	return fromAccept(r.Header.Get("Accept")), nil
+}
+
+// fromAccept returns the supported format with the highest quality in accept.
+func fromAccept(accept string) Format {
+	best, bestQ := FormatJSON, 0.0
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		if f, ok := matchMediaType(mediaType); ok {
+			best, bestQ = f, q
+		}
+	}
+	return best
+}
+
+// matchMediaType resolves a media range, including wildcards, to a format.
+func matchMediaType(mediaType string) (Format, bool) {
+	if f, ok := mediaTypes[mediaType]; ok {
+		return f, true
+	}
+	switch mediaType {
+	case "*/*", "application/*":
+		return FormatJSON, true
+	case "text/*":
+		return FormatText, true
+	}
+	return "", false
+}
+
+// Renderer renders elements in the supported formats. The zero value and a
+// nil *Renderer render without templates.
+type Renderer struct {
+	text *texttemplate.Template // Used for FormatText (nil = built-in rendering)
+	html *htmltemplate.Template // Used for FormatHTML (nil = built-in rendering)
+}
+
+// Element renders a single element in format f.
+func (r *Renderer) Element(f Format, elem data.Element) ([]byte, error) {
+	switch f {
+	case FormatText:
+		return r.textElement(elem)
+	case FormatHTML:
+		return r.htmlElements([]data.Element{elem}, false)
+	case FormatXML:
+		return xmlElements([]data.Element{elem}, false)
+	default:
+		return elem, nil
+	}
+}
+
+// Elements renders a list of elements in format f.
+func (r *Renderer) Elements(f Format, elems []data.Element) ([]byte, error) {
+	switch f {
+	case FormatText:
+		var buf bytes.Buffer
+		for _, elem := range elems {
+			text, err := r.textElement(elem)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(text)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	case FormatHTML:
+		return r.htmlElements(elems, true)
+	case FormatXML:
+		return xmlElements(elems, true)
+	default:
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(elem)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	}
+}
+
+// textElement renders elem through the text template, if any. Otherwise
+// string elements are rendered unquoted and everything else as JSON.
+func (r *Renderer) textElement(elem data.Element) ([]byte, error) {
+	if r != nil && r.text != nil {
+		return executeTemplate(r.text, elem)
+	}
+
+	var s string
+	if err := json.Unmarshal(elem, &s); err == nil {
+		return []byte(s), nil
+	}
+	return elem, nil
+}
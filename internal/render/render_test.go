@@ -0,0 +1,133 @@
+package render_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   render.Format
+	}{
+		{name: "default is JSON", target: "/random", want: render.FormatJSON},
+		{name: "wildcard is JSON", target: "/random", accept: "*/*", want: render.FormatJSON},
+		{name: "plain text", target: "/random", accept: "text/plain", want: render.FormatText},
+		{name: "text wildcard", target: "/random", accept: "text/*", want: render.FormatText},
+		{name: "browser prefers HTML", target: "/random", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: render.FormatHTML},
+		{name: "quality wins", target: "/random", accept: "application/json;q=0.5, application/xml", want: render.FormatXML},
+		{name: "text/xml", target: "/random", accept: "text/xml", want: render.FormatXML},
+		{name: "unsupported falls back to JSON", target: "/random", accept: "image/png", want: render.FormatJSON},
+		{name: "query overrides accept", target: "/random?format=TEXT", accept: "text/html", want: render.FormatText},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			got, err := render.Negotiate(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("unknown format parameter", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := render.Negotiate(httptest.NewRequest(http.MethodGet, "/random?format=yaml", nil))
+		assert.EqualError(t, err, `unsupported format "yaml"`)
+	})
+}
+
+func TestElement(t *testing.T) {
+	t.Parallel()
+
+	object := data.Element(`{"setup":"<Why>","tags":["a","b"],"n":1.50,"ok":true,"none":null,"1st":"x"}`)
+
+	tests := []struct {
+		name   string
+		format render.Format
+		elem   data.Element
+		want   string
+	}{
+		{name: "json is raw", format: render.FormatJSON, elem: object, want: string(object)},
+		{name: "text unquotes strings", format: render.FormatText, elem: data.Element(`"say \"hi\""`), want: `say "hi"`},
+		{name: "text keeps objects as JSON", format: render.FormatText, elem: data.Element(`{"a":1}`), want: `{"a":1}`},
+		{
+			name:   "html renders object in order",
+			format: render.FormatHTML,
+			elem:   object,
+			want: `<div class="randomapi-element"><dl><dt>setup</dt><dd>&lt;Why&gt;</dd><dt>tags</dt><dd><ul><li>a</li><li>b</li></ul></dd>` +
+				`<dt>n</dt><dd>1.50</dd><dt>ok</dt><dd>true</dd><dt>none</dt><dd></dd><dt>1st</dt><dd>x</dd></dl></div>` + "\n",
+		},
+		{name: "html escapes strings", format: render.FormatHTML, elem: data.Element(`"a & b"`), want: `<div class="randomapi-element">a &amp; b</div>` + "\n"},
+		{
+			name:   "xml renders object in order",
+			format: render.FormatXML,
+			elem:   object,
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<element><setup>&lt;Why&gt;</setup><tags><item>a</item><item>b</item></tags><n>1.50</n><ok>true</ok><none></none>` +
+				`<field name="1st">x</field></element>` + "\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := (&render.Renderer{}).Element(tc.format, tc.elem)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestElements(t *testing.T) {
+	t.Parallel()
+
+	elems := []data.Element{data.Element(`"a"`), data.Element(`2`)}
+
+	tests := []struct {
+		format render.Format
+		want   string
+	}{
+		{format: render.FormatJSON, want: `["a",2]`},
+		{format: render.FormatText, want: "a\n2\n"},
+		{format: render.FormatHTML, want: `<div class="randomapi-elements"><div class="randomapi-element">a</div><div class="randomapi-element">2</div></div>` + "\n"},
+		{format: render.FormatXML, want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<elements><element>a</element><element>2</element></elements>` + "\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.format), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := (&render.Renderer{}).Elements(tc.format, elems)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "application/json", render.FormatJSON.ContentType())
+	assert.Equal(t, "text/plain; charset=utf-8", render.FormatText.ContentType())
+	assert.Equal(t, "text/html; charset=utf-8", render.FormatHTML.ContentType())
+	assert.Equal(t, "application/xml; charset=utf-8", render.FormatXML.ContentType())
+}
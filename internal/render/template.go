@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates holds the template sources for a Renderer. A value starting with
+// "@" names a file to read the template from.
+type Templates struct {
+	Text string // text/template used for text/plain responses
+	HTML string // html/template used for text/html responses
+}
+
+// executor is implemented by text/template and html/template templates.
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// New returns a Renderer using the given templates. Empty templates keep the
+// built-in rendering for their format. Referencing a field an element lacks
+// is an execution error instead of rendering "<no value>".
+func New(templates Templates) (*Renderer, error) {
+	r := &Renderer{}
+
+	if templates.Text != "" {
+		src, err := templateSource(templates.Text)
+		if err != nil {
+			return nil, err
+		}
+		if r.text, err = texttemplate.New("text").Option("missingkey=error").Parse(src); err != nil {
+			return nil, fmt.Errorf("parse text template: %w", err)
+		}
+	}
+
+	if templates.HTML != "" {
+		src, err := templateSource(templates.HTML)
+		if err != nil {
+			return nil, err
+		}
+		if r.html, err = htmltemplate.New("html").Option("missingkey=error").Parse(src); err != nil {
+			return nil, fmt.Errorf("parse HTML template: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// templateSource returns tmpl, or the content of the file it names with "@".
+func templateSource(tmpl string) (string, error) {
+	path, ok := strings.CutPrefix(tmpl, "@")
+	if !ok {
+		return tmpl, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read template: %w", err)
+	}
+	return string(content), nil
+}
+
+// executeTemplate renders elem through tmpl. Objects are passed as maps so
+// fields can be referenced as {{.field}}; numbers keep their literal form.
+func executeTemplate(tmpl executor, elem []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(elem))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode element: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+package render_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	joke := data.Element(`{"setup":"Why <b>?","punchline":"Because.","id":12}`)
+
+	t.Run("text template renders fields", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := render.New(render.Templates{Text: "{{.setup}} — {{.punchline}} (#{{.id}})"})
+		require.NoError(t, err)
+
+		got, err := r.Element(render.FormatText, joke)
+		require.NoError(t, err)
+		assert.Equal(t, "Why <b>? — Because. (#12)", string(got))
+
+		got, err = r.Elements(render.FormatText, []data.Element{joke, joke})
+		require.NoError(t, err)
+		assert.Equal(t, "Why <b>? — Because. (#12)\nWhy <b>? — Because. (#12)\n", string(got))
+	})
+
+	t.Run("html template escapes fields", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := render.New(render.Templates{HTML: "<p>{{.setup}}</p>"})
+		require.NoError(t, err)
+
+		got, err := r.Element(render.FormatHTML, joke)
+		require.NoError(t, err)
+		assert.Equal(t, `<div class="randomapi-element"><p>Why &lt;b&gt;?</p></div>`+"\n", string(got))
+
+		got, err = r.Element(render.FormatText, data.Element(`"plain"`))
+		require.NoError(t, err)
+		assert.Equal(t, "plain", string(got), "text keeps built-in rendering")
+	})
+
+	t.Run("string elements are passed as dot", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := render.New(render.Templates{Text: "> {{.}}"})
+		require.NoError(t, err)
+
+		got, err := r.Element(render.FormatText, data.Element(`"hello"`))
+		require.NoError(t, err)
+		assert.Equal(t, "> hello", string(got))
+	})
+
+	t.Run("reads template from file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "joke.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte("{{.punchline}}"), 0o600))
+
+		r, err := render.New(render.Templates{Text: "@" + path})
+		require.NoError(t, err)
+
+		got, err := r.Element(render.FormatText, joke)
+		require.NoError(t, err)
+		assert.Equal(t, "Because.", string(got))
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := render.New(render.Templates{Text: "{{.setup"})
+		assert.ErrorContains(t, err, "parse text template:")
+
+		_, err = render.New(render.Templates{HTML: "{{end}}"})
+		assert.ErrorContains(t, err, "parse HTML template:")
+
+		_, err = render.New(render.Templates{Text: "@" + filepath.Join(t.TempDir(), "missing.tmpl")})
+		assert.ErrorContains(t, err, "read template:")
+	})
+
+	t.Run("execution errors", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := render.New(render.Templates{Text: "{{.setup.nested}}"})
+		require.NoError(t, err)
+
+		_, err = r.Element(render.FormatText, joke)
+		assert.ErrorContains(t, err, "execute template:")
+	})
+
+	t.Run("missing fields are errors", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := render.New(render.Templates{
+			Text: "{{.setup}} ({{.author}})",
+			HTML: "<p>{{.author}}</p>",
+		})
+		require.NoError(t, err)
+
+		_, err = r.Element(render.FormatText, joke)
+		assert.ErrorContains(t, err, `map has no entry for key "author"`)
+		_, err = r.Element(render.FormatHTML, joke)
+		assert.ErrorContains(t, err, `map has no entry for key "author"`)
+
+		// index tolerates optional fields.
+		r, err = render.New(render.Templates{Text: `{{.setup}}{{with index . "author"}} ({{.}}){{end}}`})
+		require.NoError(t, err)
+		got, err := r.Element(render.FormatText, joke)
+		require.NoError(t, err)
+		assert.Equal(t, "Why <b>?", string(got))
+	})
+}
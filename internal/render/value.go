@@ -0,0 +1,88 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// kind is the JSON type of a value.
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// value is a decoded JSON value that keeps the key order of objects.
+type value struct {
+	kind  kind
+	text  string   // scalar text: string content, number or bool literal
+	keys  []string // object keys in document order
+	items []value  // object values (parallel to keys) or array items
+}
+
+// parseValue decodes raw into a value.
+func parseValue(raw []byte) (value, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return value{}, fmt.Errorf("decode element: %w", err)
+	}
+	return v, nil
+}
+
+// decodeValue reads the next value from dec.
+func decodeValue(dec *json.Decoder) (value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return value{}, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			v := value{kind: kindArray}
+			for dec.More() {
+				item, err := decodeValue(dec)
+				if err != nil {
+					return value{}, err
+				}
+				v.items = append(v.items, item)
+			}
+			_, err := dec.Token() // ]
+			return v, err
+		}
+		v := value{kind: kindObject}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return value{}, err
+			}
+			item, err := decodeValue(dec)
+			if err != nil {
+				return value{}, err
+			}
+			v.keys = append(v.keys, keyTok.(string))
+			v.items = append(v.items, item)
+		}
+		_, err := dec.Token() // }
+		return v, err
+	case string:
+		return value{kind: kindString, text: t}, nil
+	case json.Number:
+		return value{kind: kindNumber, text: t.String()}, nil
+	case bool:
+		if t {
+			return value{kind: kindBool, text: "true"}, nil
+		}
+		return value{kind: kindBool, text: "false"}, nil
+	default:
+		return value{kind: kindNull}, nil
+	}
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"unicode"
+
+	"github.com/gi8lino/randomapi/internal/data"
+)
+
+// xmlElements renders elements as an XML document. A single element is the
+// root <element>; with list set, the elements are wrapped in <elements>.
+func xmlElements(elems []data.Element, list bool) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if list {
+		buf.WriteString("<elements>")
+	}
+	for _, elem := range elems {
+		v, err := parseValue(elem)
+		if err != nil {
+			return nil, err
+		}
+		writeXMLValue(&buf, "element", v)
+	}
+	if list {
+		buf.WriteString("</elements>")
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// writeXMLValue writes v as an XML element called name. Object keys become
+// child elements, or <field name="..."> when the key is not a valid XML name;
+// array items become <item> children.
+func writeXMLValue(buf *bytes.Buffer, name string, v value) {
+	closing := "</" + name + ">"
+	if validXMLName(name) {
+		buf.WriteString("<" + name + ">")
+	} else {
+		buf.WriteString(`<field name="`)
+		xml.EscapeText(buf, []byte(name)) // nolint:errcheck // bytes.Buffer never fails
+		buf.WriteString(`">`)
+		closing = "</field>"
+	}
+
+	switch v.kind {
+	case kindObject:
+		for i, key := range v.keys {
+			writeXMLValue(buf, key, v.items[i])
+		}
+	case kindArray:
+		for _, item := range v.items {
+			writeXMLValue(buf, "item", item)
+		}
+	case kindNull:
+	default:
+		xml.EscapeText(buf, []byte(v.text)) // nolint:errcheck // bytes.Buffer never fails
+	}
+
+	buf.WriteString(closing)
+}
+
+// validXMLName reports whether name can be used as an XML element name.
+// Names starting with "xml" are reserved.
+func validXMLName(name string) bool {
+	if name == "" || len(name) >= 3 && (name[0]|0x20) == 'x' && (name[1]|0x20) == 'm' && (name[2]|0x20) == 'l' {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -3,26 +3,206 @@ package routes
 import (
 	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/containeroo/httpprefix"
 	"github.com/gi8lino/randomapi/internal/data"
 	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/gi8lino/randomapi/internal/metrics"
+	"github.com/gi8lino/randomapi/internal/middleware"
+	"github.com/gi8lino/randomapi/internal/openapi"
+	"github.com/gi8lino/randomapi/internal/render"
 )
 
+// defaultDataset names the dataset served at the root in metrics and /readyz.
+const defaultDataset = "default"
+
+// Options configures optional router behaviour.
+type Options struct {
+	Seed            int64                   // Seed for the shared random generator (0 = seeded from the current time)
+	MaxCount        int                     // Maximum number of elements returned by /random?count=N
+	Renderer        *render.Renderer        // Renders elements in the negotiated format (nil = built-in rendering)
+	Decks           *handlers.Decks         // Per-client shuffle bags for /random (nil = random picks)
+	Location        *time.Location          // Time zone for /daily, /hourly and /period (nil = local time)
+	IndexMaxAge     time.Duration           // Cache-Control max-age for /index/{nr} (0 = clients must revalidate)
+	CompressMinSize int                     // Compress responses of at least this many bytes (0 = disabled)
+	RateLimiter     *middleware.RateLimiter // Per-client rate limits (nil = unlimited)
+	APIKeys         []middleware.APIKey     // Keys required for every request (empty = no authentication)
+	AuthExempt      []string                // Path patterns served without an API key
+	CORS            middleware.CORSOptions  // Cross-origin access (no allowed origins = disabled)
+	AdminToken      string                  // Bearer token enabling the /admin API ("" = disabled)
+	OpenAPISchema   bool                    // Infer element schemas from the data in /openapi.json
+
+	Metrics         *metrics.Metrics // Collects request and dataset metrics (nil = disabled)
+	MetricsEndpoint bool             // Serve /metrics on this router
+
+	Version           string              // Version reported by /readyz
+	DataSource        string              // Path or URL of the default dataset, reported by /readyz
+	Readiness         *handlers.Readiness // Shutdown state for /readyz (nil = never draining)
+	MaxReloadFailures uint64              // Consecutive reload failures before /readyz fails (0 = ignore)
+
+	AccessLog           bool // Log one line per request
+	AccessLogSkipHealth bool // Do not log /healthz and /readyz requests
+}
+
 // NewRouter creates and wires the HTTP mux with handlers and middleware;
-// mounts under routePrefix if provided.
+// mounts under routePrefix if provided. The default store is served at the
+// root and may be nil when only named datasets are configured.
 func NewRouter(
 	logger *slog.Logger,
 	routePrefix string,
-	elements data.Elements,
+	store *data.Store,
+	datasets []data.Dataset,
+	opts Options,
 ) http.Handler {
 	root := http.NewServeMux()
+	rnd := handlers.NewRand(opts.Seed)
 
 	root.Handle("GET /healthz", handlers.Healthz())
 	root.Handle("POST /healthz", handlers.Healthz())
 
-	root.Handle("GET /random", handlers.RandomElement(elements, logger))
-	root.Handle("GET /index/{nr}", handlers.IndexElement(elements, logger))
+	readiness := opts.Readiness
+	if readiness == nil {
+		readiness = &handlers.Readiness{}
+	}
+	served := datasets
+	if store != nil {
+		served = append([]data.Dataset{{Name: defaultDataset, Path: opts.DataSource, Store: store}}, datasets...)
+	}
+	root.Handle("GET /readyz", handlers.Readyz(opts.Version, served, readiness, opts.MaxReloadFailures, logger))
+
+	if opts.Metrics != nil && opts.MetricsEndpoint {
+		root.Handle("GET /metrics", opts.Metrics.Handler())
+	}
+
+	if store != nil {
+		root.Handle("GET /random", handlers.RandomElement(store, rnd, opts.randomOptions(defaultDataset, store), logger))
+		root.Handle("GET /index/{nr}", handlers.IndexElement(store, opts.indexOptions(), logger))
+		registerPeriods(root, "", store, opts.periodOptions(), logger)
+	}
+
+	root.Handle("GET /datasets", handlers.Datasets(datasets, logger))
+	root.Handle("GET "+openapi.Path, openapi.Handler(openapi.Options{
+		Version:     opts.Version,
+		Prefix:      routePrefix,
+		Store:       store,
+		Datasets:    datasets,
+		MaxCount:    opts.MaxCount,
+		InferSchema: opts.OpenAPISchema,
+		Metrics:     opts.Metrics != nil && opts.MetricsEndpoint,
+		Admin:       opts.AdminToken != "",
+		APIKeys:     len(opts.APIKeys) > 0,
+		AuthExempt:  opts.AuthExempt,
+		RateLimit:   opts.RateLimiter != nil,
+	}, logger))
+	for _, ds := range datasets {
+		dsLog := logger.With("dataset", ds.Name)
+		root.Handle("GET /"+ds.Name+"/random", handlers.RandomElement(ds.Store, rnd, opts.randomOptions(ds.Name, ds.Store), dsLog))
+		root.Handle("GET /"+ds.Name+"/index/{nr}", handlers.IndexElement(ds.Store, opts.indexOptions(), dsLog))
+		registerPeriods(root, "/"+ds.Name, ds.Store, opts.periodOptions(), dsLog)
+	}
+
+	if opts.AdminToken != "" {
+		adminLog := logger.With("api", "admin")
+		requireToken := middleware.RequireBearerToken(opts.AdminToken, adminLog)
+		if store != nil {
+			registerAdmin(root, requireToken, "/admin", store, adminLog)
+		}
+		for _, ds := range datasets {
+			registerAdmin(root, requireToken, "/admin/"+ds.Name, ds.Store, adminLog.With("dataset", ds.Name))
+		}
+	}
+
+	var handler http.Handler = root
+	if opts.Metrics != nil {
+		handler = middleware.Metrics(opts.Metrics)(handler)
+	}
+
+	if opts.CompressMinSize > 0 {
+		handler = middleware.Compress(opts.CompressMinSize, logger.With("middleware", "compress"))(handler)
+	}
+
+	if len(opts.APIKeys) > 0 {
+		exempt := opts.AuthExempt
+		if opts.AdminToken != "" {
+			// The admin API is guarded by its own bearer token.
+			exempt = append(slices.Clip(exempt), adminPatterns(store, datasets)...)
+		}
+		handler = middleware.RequireAPIKey(opts.APIKeys, exempt, logger.With("middleware", "auth"))(handler)
+	}
+
+	if opts.RateLimiter != nil {
+		handler = opts.RateLimiter.Middleware("/healthz", "/readyz")(handler)
+	}
+
+	// Preflights carry no credentials, so CORS wraps authentication and
+	// rate limiting; their 401 and 429 responses stay readable by browsers.
+	if len(opts.CORS.AllowedOrigins) > 0 {
+		handler = middleware.CORS(opts.CORS)(handler)
+	}
 
-	return httpprefix.MountUnderPrefix(root, routePrefix)
+	handler = httpprefix.MountUnderPrefix(handler, routePrefix)
+	if opts.AccessLog {
+		var skip []string
+		if opts.AccessLogSkipHealth {
+			skip = []string{routePrefix + "/healthz", routePrefix + "/readyz"}
+		}
+		handler = middleware.AccessLog(logger.With("middleware", "access"), skip...)(handler)
+	}
+
+	return handler
+}
+
+// randomOptions returns the /random options for dataset and registers its
+// store with the metrics, if enabled.
+func (o Options) randomOptions(dataset string, store *data.Store) handlers.RandomOptions {
+	ro := handlers.RandomOptions{MaxCount: o.MaxCount, Renderer: o.Renderer, Decks: o.Decks, Dataset: dataset}
+	if o.Metrics != nil {
+		o.Metrics.RegisterDataset(dataset, store)
+		ro.OnPick = o.Metrics.PickObserver(dataset)
+	}
+	return ro
+}
+
+// indexOptions returns the options for the index handlers.
+func (o Options) indexOptions() handlers.IndexOptions {
+	return handlers.IndexOptions{Renderer: o.Renderer, MaxAge: o.IndexMaxAge, Private: len(o.APIKeys) > 0}
+}
+
+// periodOptions returns the options for the element-of-the-period handlers.
+func (o Options) periodOptions() handlers.PeriodOptions {
+	return handlers.PeriodOptions{Location: o.Location, Renderer: o.Renderer, Private: len(o.APIKeys) > 0}
+}
+
+// registerPeriods mounts the element-of-the-period endpoints for store below base.
+func registerPeriods(mux *http.ServeMux, base string, store *data.Store, opts handlers.PeriodOptions, logger *slog.Logger) {
+	mux.Handle("GET "+base+"/daily", handlers.DailyElement(store, opts, logger))
+	mux.Handle("GET "+base+"/hourly", handlers.HourlyElement(store, opts, logger))
+	mux.Handle("GET "+base+"/period/{duration}", handlers.PeriodElement(store, opts, logger))
+}
+
+// adminPatterns returns the auth path patterns of the registered admin routes.
+func adminPatterns(store *data.Store, datasets []data.Dataset) []string {
+	var patterns []string
+	if store != nil {
+		patterns = append(patterns, "/admin/elements/*")
+	}
+	for _, ds := range datasets {
+		patterns = append(patterns, "/admin/"+ds.Name+"/elements/*")
+	}
+	return patterns
+}
+
+// registerAdmin mounts the element editing endpoints for store below base.
+func registerAdmin(
+	mux *http.ServeMux,
+	requireToken func(http.Handler) http.Handler,
+	base string,
+	store *data.Store,
+	logger *slog.Logger,
+) {
+	mux.Handle("POST "+base+"/elements", requireToken(handlers.AddElement(store, logger)))
+	mux.Handle("PUT "+base+"/elements/{nr}", requireToken(handlers.UpdateElement(store, logger)))
+	mux.Handle("DELETE "+base+"/elements/{nr}", requireToken(handlers.DeleteElement(store, logger)))
 }
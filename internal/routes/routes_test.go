@@ -1,13 +1,18 @@
 package routes_test
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gi8lino/randomapi/internal/data"
+	"github.com/gi8lino/randomapi/internal/handlers"
+	"github.com/gi8lino/randomapi/internal/metrics"
+	"github.com/gi8lino/randomapi/internal/middleware"
 	"github.com/gi8lino/randomapi/internal/routes"
 
 	"github.com/stretchr/testify/assert"
@@ -23,7 +28,7 @@ func TestNewRouter(t *testing.T) {
 		t.Parallel()
 
 		elements := data.Elements{} // not used by health handler
-		router := routes.NewRouter(logger, "", elements)
+		router := routes.NewRouter(logger, "", newStore(t, elements), nil, routes.Options{})
 
 		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
 		rec := httptest.NewRecorder()
@@ -41,7 +46,7 @@ func TestNewRouter(t *testing.T) {
 		t.Parallel()
 
 		elements := data.Elements{}
-		router := routes.NewRouter(logger, "", elements)
+		router := routes.NewRouter(logger, "", newStore(t, elements), nil, routes.Options{})
 
 		req := httptest.NewRequest(http.MethodPost, "/healthz", nil)
 		rec := httptest.NewRecorder()
@@ -63,7 +68,7 @@ func TestNewRouter(t *testing.T) {
 			[]byte(`{"msg":"second"}`),
 		}
 
-		router := routes.NewRouter(logger, "", elements)
+		router := routes.NewRouter(logger, "", newStore(t, elements), nil, routes.Options{})
 
 		req := httptest.NewRequest(http.MethodGet, "/random", nil)
 		rec := httptest.NewRecorder()
@@ -93,7 +98,7 @@ func TestNewRouter(t *testing.T) {
 			[]byte(`{"msg":"second"}`),
 		}
 
-		router := routes.NewRouter(logger, "", elements)
+		router := routes.NewRouter(logger, "", newStore(t, elements), nil, routes.Options{})
 
 		req := httptest.NewRequest(http.MethodGet, "/index/1", nil)
 		rec := httptest.NewRecorder()
@@ -115,7 +120,7 @@ func TestNewRouter(t *testing.T) {
 			[]byte(`"value"`),
 		}
 
-		router := routes.NewRouter(logger, "/api", elements)
+		router := routes.NewRouter(logger, "/api", newStore(t, elements), nil, routes.Options{})
 
 		t.Run("health under prefix", func(t *testing.T) {
 			t.Parallel()
@@ -164,4 +169,326 @@ func TestNewRouter(t *testing.T) {
 			assert.Equal(t, `"value"`, strings.TrimSpace(rec.Body.String()))
 		})
 	})
+
+	t.Run("named datasets", func(t *testing.T) {
+		t.Parallel()
+
+		datasets := []data.Dataset{
+			{Name: "jokes", Store: newStore(t, data.Elements{[]byte(`"joke"`)})},
+			{Name: "quotes", Store: newStore(t, data.Elements{[]byte(`"quote0"`), []byte(`"quote1"`)})},
+		}
+
+		router := routes.NewRouter(logger, "", nil, datasets, routes.Options{})
+
+		tests := []struct {
+			path   string
+			status int
+			body   string
+		}{
+			{path: "/jokes/random", status: http.StatusOK, body: `"joke"`},
+			{path: "/quotes/index/1", status: http.StatusOK, body: `"quote1"`},
+			{path: "/jokes/daily", status: http.StatusOK, body: `"joke"`},
+			{path: "/jokes/hourly", status: http.StatusOK, body: `"joke"`},
+			{path: "/jokes/period/15m", status: http.StatusOK, body: `"joke"`},
+			{path: "/datasets", status: http.StatusOK, body: `[{"name":"jokes","count":1},{"name":"quotes","count":2}]`},
+			{path: "/tips/random", status: http.StatusNotFound},
+			{path: "/random", status: http.StatusNotFound},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.path, func(t *testing.T) {
+				t.Parallel()
+
+				req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+				rec := httptest.NewRecorder()
+
+				router.ServeHTTP(rec, req)
+
+				require.Equal(t, tc.status, rec.Code)
+				if tc.body != "" {
+					assert.Equal(t, tc.body, strings.TrimSpace(rec.Body.String()))
+				}
+			})
+		}
+	})
+
+	t.Run("admin API", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, data.Elements{[]byte(`"a"`)})
+		jokes := newStore(t, data.Elements{[]byte(`"joke"`)})
+		datasets := []data.Dataset{{Name: "jokes", Store: jokes}}
+		router := routes.NewRouter(logger, "/api", store, datasets, routes.Options{AdminToken: "s3cret"})
+
+		tests := []struct {
+			method string
+			path   string
+			token  string
+			body   string
+			status int
+		}{
+			{method: http.MethodPost, path: "/api/admin/elements", body: `"b"`, status: http.StatusUnauthorized},
+			{method: http.MethodPost, path: "/api/admin/elements", token: "s3cret", body: `"b"`, status: http.StatusCreated},
+			{method: http.MethodPut, path: "/api/admin/jokes/elements/0", token: "s3cret", body: `"pun"`, status: http.StatusOK},
+			{method: http.MethodDelete, path: "/api/admin/jokes/elements/0", token: "s3cret", status: http.StatusConflict},
+		}
+		for _, tc := range tests {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.status, rec.Code, "%s %s", tc.method, tc.path)
+		}
+
+		assert.Len(t, store.Elements(), 2)
+		assert.Equal(t, `"pun"`, string(jokes.Elements()[0]))
+	})
+
+	t.Run("admin API disabled without token", func(t *testing.T) {
+		t.Parallel()
+
+		router := routes.NewRouter(logger, "", newStore(t, data.Elements{[]byte(`"a"`)}), nil, routes.Options{})
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/elements", strings.NewReader(`"b"`))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("GET /metrics", func(t *testing.T) {
+		t.Parallel()
+
+		m := metrics.New()
+		datasets := []data.Dataset{{Name: "jokes", Store: newStore(t, data.Elements{[]byte(`"joke"`)})}}
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`"a"`)}), datasets, routes.Options{
+			MaxCount:        10,
+			Metrics:         m,
+			MetricsEndpoint: true,
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/jokes/random", nil))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `randomapi_http_requests_total{code="200",route="GET /jokes/random"} 1`)
+		assert.Contains(t, rec.Body.String(), `randomapi_random_picks_total{dataset="jokes",index="0"} 1`)
+		assert.Contains(t, rec.Body.String(), `randomapi_dataset_elements{dataset="default"} 1`)
+	})
+
+	t.Run("metrics endpoint can be disabled", func(t *testing.T) {
+		t.Parallel()
+
+		router := routes.NewRouter(logger, "", newStore(t, data.Elements{[]byte(`"a"`)}), nil, routes.Options{Metrics: metrics.New()})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("GET /readyz", func(t *testing.T) {
+		t.Parallel()
+
+		readiness := &handlers.Readiness{}
+		datasets := []data.Dataset{{Name: "jokes", Path: "/data/jokes.json", Store: newStore(t, data.Elements{[]byte(`"joke"`)})}}
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`"a"`)}), datasets, routes.Options{
+			Version:    "v1",
+			DataSource: "/data/default.json",
+			Readiness:  readiness,
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/readyz", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"default","source":"/data/default.json"`)
+		assert.Contains(t, rec.Body.String(), `"name":"jokes","source":"/data/jokes.json"`)
+
+		readiness.StartDrain()
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("access log skips health checks under prefix", func(t *testing.T) {
+		t.Parallel()
+
+		var buf strings.Builder
+		accessLogger := slog.New(slog.NewTextHandler(&buf, nil))
+		router := routes.NewRouter(accessLogger, "/api", newStore(t, data.Elements{[]byte(`"a"`)}), nil, routes.Options{
+			MaxCount:            1,
+			AccessLog:           true,
+			AccessLogSkipHealth: true,
+		})
+
+		for _, path := range []string{"/api/healthz", "/api/readyz", "/api/random"} {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+		}
+
+		assert.Equal(t, 1, strings.Count(buf.String(), "msg=request"))
+		assert.Contains(t, buf.String(), "path=/api/random")
+		assert.Contains(t, buf.String(), "middleware=access")
+	})
+
+	t.Run("rate limit skips health checks under prefix", func(t *testing.T) {
+		t.Parallel()
+
+		limiter, err := middleware.NewRateLimiter(middleware.RateLimitOptions{Rate: 1, Burst: 1, MaxClients: 10}, logger)
+		require.NoError(t, err)
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`1`)}), nil, routes.Options{RateLimiter: limiter})
+
+		codes := make([]int, 0, 4)
+		for _, path := range []string{"/api/random", "/api/healthz", "/api/healthz", "/api/index/0"} {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			codes = append(codes, rec.Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+
+	t.Run("API keys guard routes but not exempt paths or the admin API", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := middleware.ParseAPIKey("k1 /jokes/*")
+		require.NoError(t, err)
+		datasets := []data.Dataset{
+			{Name: "jokes", Store: newStore(t, data.Elements{[]byte(`"joke"`)})},
+			{Name: "admin", Store: newStore(t, data.Elements{[]byte(`"secret"`)})},
+		}
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`"a"`)}), datasets, routes.Options{
+			APIKeys:    []middleware.APIKey{key},
+			AuthExempt: []string{"/healthz"},
+			AdminToken: "s3cret",
+		})
+
+		tests := []struct {
+			method string
+			path   string
+			header string
+			status int
+		}{
+			{method: http.MethodGet, path: "/api/jokes/random", header: "Bearer k1", status: http.StatusOK},
+			{method: http.MethodGet, path: "/api/random", header: "Bearer k1", status: http.StatusForbidden},
+			{method: http.MethodGet, path: "/api/jokes/random", status: http.StatusUnauthorized},
+			{method: http.MethodGet, path: "/api/healthz", status: http.StatusOK},
+			{method: http.MethodPost, path: "/api/admin/elements", header: "Bearer s3cret", status: http.StatusCreated},
+			{method: http.MethodGet, path: "/api/admin/random", status: http.StatusUnauthorized},
+		}
+		for _, tc := range tests {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`"b"`))
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code, "%s %s", tc.method, tc.path)
+		}
+	})
+
+	t.Run("API keys keep cacheable responses private", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := middleware.ParseAPIKey("k1")
+		require.NoError(t, err)
+		router := routes.NewRouter(logger, "", newStore(t, data.Elements{[]byte(`"a"`)}), nil, routes.Options{
+			APIKeys:     []middleware.APIKey{key},
+			IndexMaxAge: time.Minute,
+		})
+
+		for _, path := range []string{"/index/0", "/daily"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer k1")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, path)
+			assert.True(t, strings.HasPrefix(rec.Header().Get("Cache-Control"), "private, "), path)
+		}
+	})
+
+	t.Run("OpenAPI document under prefix", func(t *testing.T) {
+		t.Parallel()
+
+		datasets := []data.Dataset{{Name: "jokes", Store: newStore(t, data.Elements{[]byte(`"joke"`)})}}
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`{"text":"a"}`)}), datasets, routes.Options{
+			MaxCount:      5,
+			OpenAPISchema: true,
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var doc struct {
+			Servers []struct {
+				URL string `json:"url"`
+			} `json:"servers"`
+			Paths      map[string]any `json:"paths"`
+			Components struct {
+				Schemas map[string]map[string]any `json:"schemas"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		require.Len(t, doc.Servers, 1)
+		assert.Equal(t, "/api", doc.Servers[0].URL)
+		assert.Contains(t, doc.Paths, "/random")
+		assert.Contains(t, doc.Paths, "/jokes/period/{duration}")
+		assert.Equal(t, "object", doc.Components.Schemas["Element"]["type"])
+	})
+
+	t.Run("CORS preflight bypasses API keys under prefix", func(t *testing.T) {
+		t.Parallel()
+
+		key, err := middleware.ParseAPIKey("k1")
+		require.NoError(t, err)
+		router := routes.NewRouter(logger, "/api", newStore(t, data.Elements{[]byte(`"a"`)}), nil, routes.Options{
+			APIKeys: []middleware.APIKey{key},
+			CORS: middleware.CORSOptions{
+				AllowedOrigins: []string{"https://example.com"},
+				AllowedMethods: []string{http.MethodGet},
+				AllowedHeaders: []string{"Authorization"},
+			},
+		})
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/random", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "authorization")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+
+		req = httptest.NewRequest(http.MethodGet, "/api/random", nil)
+		req.Header.Set("Origin", "https://example.com")
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+// newStore returns a store serving elements with default options.
+func newStore(t *testing.T, elements data.Elements) *data.Store {
+	t.Helper()
+
+	store, err := data.NewStore(elements, data.Options{})
+	require.NoError(t, err)
+
+	return store
 }
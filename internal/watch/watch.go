@@ -0,0 +1,88 @@
+// Package watch reloads files when they change on disk.
+package watch
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounceDelay groups bursts of filesystem events into a single reload.
+const debounceDelay = 100 * time.Millisecond
+
+// Files calls reload whenever one of paths changes, until ctx is canceled.
+// It falls back to calling reload every interval when filesystem
+// notifications are unavailable. reload is also called once the watch is set
+// up, to catch changes made since the files were last read.
+func Files(ctx context.Context, paths []string, interval time.Duration, logger *slog.Logger, reload func()) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warn("filesystem notifications unavailable, polling files", "interval", interval, "error", err)
+		Poll(ctx, interval, reload)
+		return
+	}
+	defer fsw.Close() // nolint:errcheck
+
+	// Watch the parent directories: editors and Kubernetes ConfigMaps and
+	// Secrets replace the files (or the "..data" symlink pointing to them)
+	// instead of writing in place.
+	bases := make([]string, 0, len(paths))
+	for _, path := range paths {
+		bases = append(bases, filepath.Base(path))
+		dir := filepath.Dir(path)
+		if err := fsw.Add(dir); err != nil {
+			logger.Warn("watch directory failed, polling files", "dir", dir, "interval", interval, "error", err)
+			Poll(ctx, interval, reload)
+			return
+		}
+	}
+	logger.Debug("watching files", "paths", paths)
+
+	reload()
+
+	debounce := time.NewTimer(debounceDelay)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Base(event.Name)
+			if slices.Contains(bases, name) || strings.HasPrefix(name, "..") {
+				debounce.Reset(debounceDelay)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("watch files", "paths", paths, "error", err)
+		case <-debounce.C:
+			reload()
+		}
+	}
+}
+
+// Poll calls reload every interval until ctx is canceled.
+func Poll(ctx context.Context, interval time.Duration, reload func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reload()
+		}
+	}
+}
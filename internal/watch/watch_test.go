@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+
+	// start runs run until the test ends.
+	start := func(t *testing.T, run func(context.Context)) {
+		t.Helper()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		var wg sync.WaitGroup
+		wg.Go(func() { run(ctx) })
+		t.Cleanup(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+
+	t.Run("reloads once per burst of changes", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[1]`), 0o600))
+
+		var reloads atomic.Int32
+		start(t, func(ctx context.Context) {
+			Files(ctx, []string{path}, time.Hour, logger, func() { reloads.Add(1) })
+		})
+		require.Eventually(t, func() bool { return reloads.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+		for range 5 {
+			require.NoError(t, os.WriteFile(path, []byte(`[1, 2]`), 0o600))
+		}
+
+		require.Eventually(t, func() bool { return reloads.Load() == 2 }, 2*time.Second, 10*time.Millisecond)
+		time.Sleep(3 * debounceDelay)
+		assert.Equal(t, int32(2), reloads.Load())
+	})
+
+	t.Run("ignores other files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "tls.crt")
+		require.NoError(t, os.WriteFile(path, []byte(`cert`), 0o600))
+
+		var reloads atomic.Int32
+		start(t, func(ctx context.Context) {
+			Files(ctx, []string{path}, time.Hour, logger, func() { reloads.Add(1) })
+		})
+		require.Eventually(t, func() bool { return reloads.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte(`x`), 0o600))
+
+		time.Sleep(3 * debounceDelay)
+		assert.Equal(t, int32(1), reloads.Load())
+	})
+}
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var reloads atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Poll(ctx, 10*time.Millisecond, func() { reloads.Add(1) })
+	}()
+
+	assert.Eventually(t, func() bool { return reloads.Load() >= 2 }, 2*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}